@@ -0,0 +1,157 @@
+// Package common holds the access control and FIR lookup helpers shared by the chaincodes
+// that work alongside fir-record. Chaincodes import it through a replace directive in
+// their go.mod, and deployCC vendors it into each chaincode package.
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Police roles carried in the "role" attribute of Fabric CA enrollment certificates
+const (
+	RoleSHO       = "SHO"
+	RoleIO        = "IO"
+	RoleConstable = "Constable"
+)
+
+// MSP IDs of the organisations on the channel
+const (
+	PoliceMSP        = "Org1MSP"
+	JudiciaryMSP     = "Org2MSP"
+	CitizenPortalMSP = "Org3MSP"
+)
+
+// PoliceRoles lists every police role
+var PoliceRoles = []string{RoleSHO, RoleIO, RoleConstable}
+
+// Certificate attributes used for access control
+const (
+	attrRole      = "role"
+	attrStation   = "station"
+	attrOfficerID = "officerId"
+)
+
+// GetMSPID returns the client's MSP ID
+func GetMSPID(ctx contractapi.TransactionContextInterface) (string, error) {
+	return ctx.GetClientIdentity().GetMSPID()
+}
+
+// OnlyPolice enforces access for Org1MSP (Police)
+func OnlyPolice(ctx contractapi.TransactionContextInterface) error {
+	mspid, err := GetMSPID(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get MSP ID: %v", err)
+	}
+	if mspid != PoliceMSP {
+		return fmt.Errorf("access denied: only Org1 (Police) can perform this operation")
+	}
+	return nil
+}
+
+// OnlyJudiciary enforces access for Org2MSP (Judiciary)
+func OnlyJudiciary(ctx contractapi.TransactionContextInterface) error {
+	mspid, err := GetMSPID(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get MSP ID: %v", err)
+	}
+	if mspid != JudiciaryMSP {
+		return fmt.Errorf("access denied: only Org2 (Judiciary) can perform this operation")
+	}
+	return nil
+}
+
+// RestrictCitizenPortal runs before every transaction of a chaincode the citizen portal
+// (Org3) has no business with, and refuses it
+func RestrictCitizenPortal(ctx contractapi.TransactionContextInterface) error {
+	mspid, err := GetMSPID(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get MSP ID: %v", err)
+	}
+	if mspid == CitizenPortalMSP {
+		return fmt.Errorf("access denied: Org3 (Citizen portal) cannot use this chaincode")
+	}
+	return nil
+}
+
+// GetAttribute returns a required attribute from the caller's certificate
+func GetAttribute(ctx contractapi.TransactionContextInterface, name string) (string, error) {
+	value, found, err := ctx.GetClientIdentity().GetAttributeValue(name)
+	if err != nil {
+		return "", fmt.Errorf("unable to read %s attribute: %v", name, err)
+	}
+	if !found || value == "" {
+		return "", fmt.Errorf("access denied: certificate has no %s attribute", name)
+	}
+	return value, nil
+}
+
+// GetStation returns the caller's station attribute
+func GetStation(ctx contractapi.TransactionContextInterface) (string, error) {
+	return GetAttribute(ctx, attrStation)
+}
+
+// GetOfficerID returns the caller's officer ID from the officerId certificate attribute,
+// falling back to the certificate common name (the Fabric CA enrollment ID)
+func GetOfficerID(ctx contractapi.TransactionContextInterface) (string, error) {
+	officerID, found, err := ctx.GetClientIdentity().GetAttributeValue(attrOfficerID)
+	if err != nil {
+		return "", fmt.Errorf("unable to read %s attribute: %v", attrOfficerID, err)
+	}
+	if found && officerID != "" {
+		return officerID, nil
+	}
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return "", fmt.Errorf("unable to get client certificate: %v", err)
+	}
+	return cert.Subject.CommonName, nil
+}
+
+// RequireRole enforces that the caller is a police identity whose role attribute is one of roles
+func RequireRole(ctx contractapi.TransactionContextInterface, roles ...string) error {
+	if err := OnlyPolice(ctx); err != nil {
+		return err
+	}
+	role, err := GetAttribute(ctx, attrRole)
+	if err != nil {
+		return fmt.Errorf("%v; requires %s=%s", err, attrRole, strings.Join(roles, "|"))
+	}
+	for _, allowed := range roles {
+		if role == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("access denied: requires %s=%s, certificate has %s=%s", attrRole, strings.Join(roles, "|"), attrRole, role)
+}
+
+// RequireStation enforces that the caller's station attribute matches station
+func RequireStation(ctx contractapi.TransactionContextInterface, station string) error {
+	callerStation, err := GetAttribute(ctx, attrStation)
+	if err != nil {
+		return fmt.Errorf("%v; requires %s=%s", err, attrStation, station)
+	}
+	if callerStation != station {
+		return fmt.Errorf("access denied: requires %s=%s, certificate has %s=%s", attrStation, station, attrStation, callerStation)
+	}
+	return nil
+}
+
+// RequireStationRole enforces that the caller holds one of roles at the given station
+func RequireStationRole(ctx contractapi.TransactionContextInterface, station string, roles ...string) error {
+	if err := RequireRole(ctx, roles...); err != nil {
+		return err
+	}
+	return RequireStation(ctx, station)
+}
+
+// RequireStationOrJudiciary enforces that the caller is in the judiciary or holds one of
+// roles at the given station
+func RequireStationOrJudiciary(ctx contractapi.TransactionContextInterface, station string, roles ...string) error {
+	if OnlyJudiciary(ctx) == nil {
+		return nil
+	}
+	return RequireStationRole(ctx, station, roles...)
+}
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// FIRChaincode is the name the fir-record chaincode is deployed under on the same channel
+const FIRChaincode = "fir"
+
+// maxTransfers bounds how many Zero FIR transfers CurrentFIR follows
+const maxTransfers = 10
+
+// FIR holds the fields of a fir-record FIR that other chaincodes need
+type FIR struct {
+	FIRID   string `json:"FIRID"`
+	Station string `json:"Station"`
+	Status  string `json:"Status"`
+
+	// TransferredFrom and TransferredTo link the FIR numbers of a case transferred between
+	// stations; see fir-record's zerofir.go
+	TransferredFrom string `json:"TransferredFrom,omitempty"`
+	TransferredTo   string `json:"TransferredTo,omitempty"`
+}
+
+// ReadFIR fetches a FIR from the fir-record chaincode. The call runs with the caller's
+// identity, and sealed FIRs are refused by fir-record.
+func ReadFIR(ctx contractapi.TransactionContextInterface, firID string) (*FIR, error) {
+	response := ctx.GetStub().InvokeChaincode(FIRChaincode, [][]byte{[]byte("ReadFIR"), []byte(firID)}, "")
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("unable to verify FIR %s with the %s chaincode: %s", firID, FIRChaincode, response.Message)
+	}
+
+	var fir FIR
+	err := json.Unmarshal(response.Payload, &fir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse FIR %s from the %s chaincode: %v", firID, FIRChaincode, err)
+	}
+	return &fir, nil
+}
+
+// CurrentFIR follows a FIR's Zero FIR transfers to the number the case now continues under
+func CurrentFIR(ctx contractapi.TransactionContextInterface, firID string) (*FIR, error) {
+	fir, err := ReadFIR(ctx, firID)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; fir.TransferredTo != ""; i++ {
+		if i == maxTransfers {
+			return nil, fmt.Errorf("the FIR %s has been transferred more than %d times", firID, maxTransfers)
+		}
+		if fir, err = ReadFIR(ctx, fir.TransferredTo); err != nil {
+			return nil, err
+		}
+	}
+	return fir, nil
+}
+
+// FIRNumbers returns every number a case has been registered under up to fir, oldest first
+func FIRNumbers(ctx contractapi.TransactionContextInterface, fir *FIR) ([]string, error) {
+	numbers := []string{fir.FIRID}
+	for i := 0; fir.TransferredFrom != ""; i++ {
+		if i == maxTransfers {
+			return nil, fmt.Errorf("the FIR %s has been transferred more than %d times", numbers[0], maxTransfers)
+		}
+		previous, err := ReadFIR(ctx, fir.TransferredFrom)
+		if err != nil {
+			return nil, err
+		}
+		fir = previous
+		numbers = append([]string{fir.FIRID}, numbers...)
+	}
+	return numbers, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// arrestCommand records an arrest or a later production, remand or release, or lists
+// arrests under a FIR and arrestees overdue for production
+func arrestCommand(contract *client.Contract, args []string) {
+	switch {
+	case len(args) == 5 && args[0] == "record":
+		parseNumber("party", args[2])
+		fmt.Printf("\n--> Submit Transaction: RecordArrest, records the arrest of party %s under %s\n", args[2], args[1])
+		result, err := contract.SubmitTransaction("RecordArrest", args[1], args[2], args[3], args[4])
+		if err != nil {
+			panic(fmt.Errorf("failed to record arrest: %w", err))
+		}
+		fmt.Printf("*** Arrest %s recorded\n", result)
+	case (len(args) == 4 || len(args) == 5) && args[0] == "produce":
+		parseNumber("arrest", args[2])
+		remarks := ""
+		if len(args) == 5 {
+			remarks = args[4]
+		}
+		submitArrestEvent(contract, "RecordProduction", args[1], args[2], args[3], remarks)
+	case len(args) == 6 && args[0] == "remand":
+		parseNumber("arrest", args[2])
+		submitArrestEvent(contract, "RecordRemand", args[1], args[2], args[3], args[4], args[5])
+	case (len(args) == 4 || len(args) == 5) && args[0] == "release":
+		parseNumber("arrest", args[2])
+		orderRef := ""
+		if len(args) == 5 {
+			orderRef = args[4]
+		}
+		submitArrestEvent(contract, "RecordRelease", args[1], args[2], args[3], orderRef)
+	case len(args) == 2 && args[0] == "list":
+		fmt.Printf("\n--> Evaluate Transaction: GetArrests, returns the arrests under %s\n", args[1])
+		result, err := contract.EvaluateTransaction("GetArrests", args[1])
+		if err != nil {
+			panic(fmt.Errorf("failed to evaluate transaction: %w", err))
+		}
+		fmt.Printf("*** Result:%s\n", formatJSON(result))
+	case len(args) == 1 && args[0] == "overdue":
+		fmt.Println("\n--> Evaluate Transaction: GetOverdueProductions, returns arrestees not produced within 24 hours")
+		result, err := contract.EvaluateTransaction("GetOverdueProductions")
+		if err != nil {
+			panic(fmt.Errorf("failed to evaluate transaction: %w", err))
+		}
+		fmt.Printf("*** Result:%s\n", formatJSON(result))
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func submitArrestEvent(contract *client.Contract, transactionName string, args ...string) {
+	fmt.Printf("\n--> Submit Transaction: %s, on arrest %s under %s\n", transactionName, args[1], args[0])
+	_, err := contract.SubmitTransaction(transactionName, args...)
+	if err != nil {
+		panic(fmt.Errorf("failed to submit %s: %w", transactionName, err))
+	}
+	fmt.Printf("*** %s committed successfully\n", transactionName)
+}
+
+// parseNumber panics unless value is a whole number
+func parseNumber(name, value string) {
+	if _, err := strconv.Atoi(value); err != nil {
+		panic(fmt.Errorf("invalid %s number %q: %w", name, value, err))
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// diaryEntry mirrors the chaincode's CaseDiaryEntry
+type diaryEntry struct {
+	Seq          int      `json:"Seq"`
+	EntryDate    string   `json:"EntryDate"`
+	Author       string   `json:"Author"`
+	Text         string   `json:"Text"`
+	EvidenceRefs []string `json:"EvidenceRefs"`
+	CorrectsSeq  int      `json:"CorrectsSeq"`
+}
+
+func diaryCommand(contract *client.Contract, args []string) {
+	switch {
+	case len(args) >= 3 && args[0] == "add":
+		addCaseDiaryEntry(contract, args[1], args[2], 0, args[3:])
+	case len(args) >= 4 && args[0] == "correct":
+		correctsSeq, err := strconv.Atoi(args[2])
+		if err != nil {
+			panic(fmt.Errorf("invalid entry number %q: %w", args[2], err))
+		}
+		addCaseDiaryEntry(contract, args[1], args[3], correctsSeq, args[4:])
+	case len(args) == 2 && args[0] == "show":
+		getCaseDiary(contract, args[1])
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func addCaseDiaryEntry(contract *client.Contract, firID, text string, correctsSeq int, evidenceRefs []string) {
+	fmt.Printf("\n--> Submit Transaction: AddCaseDiaryEntry, appends to the case diary of %s\n", firID)
+
+	evidenceRefsJSON := ""
+	if len(evidenceRefs) > 0 {
+		refs, err := json.Marshal(evidenceRefs)
+		if err != nil {
+			panic(err)
+		}
+		evidenceRefsJSON = string(refs)
+	}
+
+	result, err := contract.SubmitTransaction("AddCaseDiaryEntry", firID, text, evidenceRefsJSON, strconv.Itoa(correctsSeq))
+	if err != nil {
+		panic(fmt.Errorf("failed to add case diary entry: %w", err))
+	}
+	fmt.Printf("*** Case diary entry %s recorded\n", result)
+}
+
+func getCaseDiary(contract *client.Contract, firID string) {
+	fmt.Printf("\n--> Evaluate Transaction: GetCaseDiary, walking every page for %s\n", firID)
+
+	const pageSize = 20
+	bookmark := ""
+	for {
+		result, err := contract.EvaluateTransaction("GetCaseDiary", firID, strconv.Itoa(pageSize), bookmark)
+		if err != nil {
+			panic(fmt.Errorf("failed to evaluate GetCaseDiary: %w", err))
+		}
+
+		var page struct {
+			Entries             []diaryEntry `json:"Entries"`
+			FetchedRecordsCount int32        `json:"FetchedRecordsCount"`
+			Bookmark            string       `json:"Bookmark"`
+		}
+		if err := json.Unmarshal(result, &page); err != nil {
+			panic(fmt.Errorf("failed to parse case diary page: %w", err))
+		}
+
+		for _, entry := range page.Entries {
+			fmt.Printf("[%d] %s %s", entry.Seq, entry.EntryDate, entry.Author)
+			if entry.CorrectsSeq > 0 {
+				fmt.Printf(" (corrects %d)", entry.CorrectsSeq)
+			}
+			fmt.Printf("\n    %s\n", entry.Text)
+			if len(entry.EvidenceRefs) > 0 {
+				fmt.Printf("    evidence: %v\n", entry.EvidenceRefs)
+			}
+		}
+
+		if page.Bookmark == "" || page.Bookmark == bookmark || page.FetchedRecordsCount < pageSize {
+			return
+		}
+		bookmark = page.Bookmark
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// forwardedEvent is the JSON body POSTed to EVENT_FORWARD_URL for each chaincode event
+type forwardedEvent struct {
+	ChaincodeName string          `json:"chaincodeName"`
+	EventName     string          `json:"eventName"`
+	TransactionID string          `json:"transactionId"`
+	BlockNumber   uint64          `json:"blockNumber"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// listen prints chaincode events as they are committed until interrupted. If
+// EVENT_FORWARD_URL is set, each event is also POSTed there as JSON. Pass a block
+// number to replay events from that block onwards. Payloads are forwarded as they
+// are, so setting CHAINCODE_NAME forwards the events of another chaincode.
+func listen(network *client.Network, chaincodeName string, args []string) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var options []client.ChaincodeEventsOption
+	if len(args) > 0 {
+		startBlock, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			panic(fmt.Errorf("invalid start block %q: %w", args[0], err))
+		}
+		options = append(options, client.WithStartBlock(startBlock))
+	}
+
+	events, err := network.ChaincodeEvents(ctx, chaincodeName, options...)
+	if err != nil {
+		panic(fmt.Errorf("failed to start chaincode event listening: %w", err))
+	}
+
+	forwardURL := os.Getenv("EVENT_FORWARD_URL")
+	httpClient := &http.Client{Timeout: 5 * time.Second}
+
+	fmt.Printf("\n*** Listening for %s chaincode events, press Ctrl+C to stop\n", chaincodeName)
+	for event := range events {
+		fmt.Printf("\n<-- Chaincode event received: %s (block %d, tx %s)\n%s\n", event.EventName, event.BlockNumber, event.TransactionID, formatJSON(event.Payload))
+
+		if forwardURL == "" {
+			continue
+		}
+		if err := forwardEvent(httpClient, forwardURL, event); err != nil {
+			fmt.Fprintf(os.Stderr, "*** Failed to forward event %s from tx %s: %v\n", event.EventName, event.TransactionID, err)
+		}
+	}
+}
+
+func forwardEvent(httpClient *http.Client, url string, event *client.ChaincodeEvent) error {
+	body, err := json.Marshal(forwardedEvent{
+		ChaincodeName: event.ChaincodeName,
+		EventName:     event.EventName,
+		TransactionID: event.TransactionID,
+		BlockNumber:   event.BlockNumber,
+		Payload:       event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	response, err := httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("unexpected HTTP status %s", response.Status)
+	}
+	return nil
+}
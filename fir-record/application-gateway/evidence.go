@@ -0,0 +1,174 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// evidenceRecord mirrors the fields of the chaincode's Evidence asset used here
+type evidenceRecord struct {
+	EvidenceID string `json:"EvidenceID"`
+	FIRID      string `json:"FIRID"`
+	SHA256     string `json:"SHA256"`
+	Size       int64  `json:"Size"`
+	MediaType  string `json:"MediaType"`
+}
+
+func evidenceCommand(contract *client.Contract, args []string) {
+	switch {
+	case len(args) >= 6 && args[0] == "register":
+		mediaType, description := "", ""
+		if len(args) > 6 {
+			mediaType = args[6]
+		}
+		if len(args) > 7 {
+			description = args[7]
+		}
+		registerEvidence(contract, args[1], args[2], args[3], args[4], args[5], mediaType, description)
+	case len(args) == 3 && args[0] == "verify":
+		verifyEvidence(contract, args[1], args[2])
+	case len(args) == 6 && args[0] == "transfer":
+		initiateTransfer(contract, args[1], args[2], args[3], args[4], args[5])
+	case len(args) == 2 && args[0] == "accept":
+		acceptTransfer(contract, args[1])
+	case len(args) == 3 && (args[0] == "cancel" || args[0] == "reject"):
+		closeTransfer(contract, args[0] == "reject", args[1], args[2])
+	case len(args) == 2 && args[0] == "custody":
+		getCustodyChain(contract, args[1])
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+// hashFile returns the hex SHA-256 digest, size and sniffed media type of a file
+func hashFile(filePath string) (string, int64, string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", 0, "", err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", 0, "", err
+	}
+	mediaType := http.DetectContentType(head[:n])
+
+	hasher := sha256.New()
+	hasher.Write(head[:n])
+	rest, err := io.Copy(hasher, file)
+	if err != nil {
+		return "", 0, "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), int64(n) + rest, mediaType, nil
+}
+
+func registerEvidence(contract *client.Contract, evidenceID, firID, filePath, collectedBy, collectedAt, mediaType, description string) {
+	fmt.Printf("\n--> Submit Transaction: RegisterEvidence, anchors %s to FIR %s\n", filePath, firID)
+
+	digest, size, detectedType, err := hashFile(filePath)
+	if err != nil {
+		panic(fmt.Errorf("failed to hash evidence file: %w", err))
+	}
+	if mediaType == "" {
+		mediaType = detectedType
+	}
+
+	_, err = contract.SubmitTransaction("RegisterEvidence",
+		evidenceID,
+		firID,
+		digest,
+		strconv.FormatInt(size, 10),
+		mediaType,
+		description,
+		collectedBy,
+		collectedAt,
+	)
+	if err != nil {
+		panic(fmt.Errorf("failed to register evidence: %w", err))
+	}
+
+	fmt.Printf("*** Evidence %s registered: sha256=%s size=%d type=%s\n", evidenceID, digest, size, mediaType)
+}
+
+func verifyEvidence(contract *client.Contract, evidenceID, filePath string) {
+	fmt.Printf("\n--> Evaluate Transaction: ReadEvidence, verifies %s against evidence %s\n", filePath, evidenceID)
+
+	result, err := contract.EvaluateTransaction("ReadEvidence", evidenceID)
+	if err != nil {
+		panic(fmt.Errorf("failed to read evidence: %w", err))
+	}
+
+	var evidence evidenceRecord
+	if err := json.Unmarshal(result, &evidence); err != nil {
+		panic(fmt.Errorf("failed to parse evidence: %w", err))
+	}
+
+	digest, size, _, err := hashFile(filePath)
+	if err != nil {
+		panic(fmt.Errorf("failed to hash evidence file: %w", err))
+	}
+
+	if digest != evidence.SHA256 || size != evidence.Size {
+		fmt.Printf("*** MISMATCH: file sha256=%s size=%d, ledger sha256=%s size=%d\n", digest, size, evidence.SHA256, evidence.Size)
+		os.Exit(1)
+	}
+	fmt.Printf("*** Verified: %s matches evidence %s of FIR %s (sha256=%s)\n", filePath, evidence.EvidenceID, evidence.FIRID, digest)
+}
+
+func initiateTransfer(contract *client.Contract, evidenceID, toHolder, toMSP, toHolderType, reason string) {
+	fmt.Printf("\n--> Submit Transaction: InitiateTransfer, hands evidence %s to %s (%s)\n", evidenceID, toHolder, toMSP)
+
+	_, err := contract.SubmitTransaction("InitiateTransfer", evidenceID, toHolder, toMSP, toHolderType, reason)
+	if err != nil {
+		panic(fmt.Errorf("failed to initiate custody transfer: %w", err))
+	}
+	fmt.Println("*** Transfer initiated; awaiting acceptance by the receiver")
+}
+
+func acceptTransfer(contract *client.Contract, evidenceID string) {
+	fmt.Printf("\n--> Submit Transaction: AcceptTransfer, takes custody of evidence %s\n", evidenceID)
+
+	_, err := contract.SubmitTransaction("AcceptTransfer", evidenceID)
+	if err != nil {
+		panic(fmt.Errorf("failed to accept custody transfer: %w", err))
+	}
+	fmt.Println("*** Custody transfer accepted")
+}
+
+// closeTransfer cancels the open handoff of an evidence item as its holder, or rejects it
+// as the named receiver
+func closeTransfer(contract *client.Contract, reject bool, evidenceID, reason string) {
+	transactionName := "CancelTransfer"
+	if reject {
+		transactionName = "RejectTransfer"
+	}
+	fmt.Printf("\n--> Submit Transaction: %s, closes the open transfer of evidence %s\n", transactionName, evidenceID)
+
+	_, err := contract.SubmitTransaction(transactionName, evidenceID, reason)
+	if err != nil {
+		panic(fmt.Errorf("failed to submit %s: %w", transactionName, err))
+	}
+	fmt.Println("*** Open custody transfer closed; custody is unchanged")
+}
+
+func getCustodyChain(contract *client.Contract, evidenceID string) {
+	fmt.Printf("\n--> Evaluate Transaction: GetCustodyChain, returns the handoffs of evidence %s\n", evidenceID)
+
+	result, err := contract.EvaluateTransaction("GetCustodyChain", evidenceID)
+	if err != nil {
+		panic(fmt.Errorf("failed to evaluate GetCustodyChain: %w", err))
+	}
+	fmt.Printf("*** Result: %s\n", formatJSON(result))
+}
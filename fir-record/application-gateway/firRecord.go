@@ -13,14 +13,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
 	"os"
 	"path"
+	"strconv"
 	"time"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
 	"github.com/hyperledger/fabric-gateway/pkg/hash"
 	"github.com/hyperledger/fabric-gateway/pkg/identity"
 	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
@@ -34,6 +37,14 @@ const (
 	tlsCertPath  = cryptoPath + "/peers/peer0.org1.example.com/tls/ca.crt"
 	peerEndpoint = "dns:///localhost:7051"
 	gatewayPeer  = "peer0.org1.example.com"
+
+	// caCertPath holds the organisation CA certificates that receipt signers must chain to,
+	// and crlPath the revocation lists those CAs issued
+	caCertPath = cryptoPath + "/msp/cacerts"
+	crlPath    = cryptoPath + "/msp/crls"
+
+	// maxSubmitAttempts bounds how often submitWithRetry resubmits a conflicting transaction
+	maxSubmitAttempts = 5
 )
 
 func main() {
@@ -71,15 +82,142 @@ func main() {
 	network := gw.GetNetwork(channelName)
 	contract := network.GetContract(chaincodeName)
 
-	initLedger(contract)
+	if len(os.Args) > 1 {
+		runCommand(network, chaincodeName, os.Args[1:])
+		return
+	}
+
+	seededFIRIDs := initLedger(contract)
 	getAllFIRs(contract)
-	createFIR(contract)
-	readFIRByID(contract)
-	updateFIR(contract)
+	firID := createFIR(contract)
+	fmt.Printf("*** Filed FIR number %s\n", firID)
+	readFIRByID(contract, seededFIRIDs[0])
+	updateFIR(contract, firID)
 	getAllFIRs(contract)
+	getFIRHistory(contract, firID)
+	listAllFIRs(contract)
 	exampleErrorHandling(contract)
 }
 
+// runCommand runs a single command given on the command line instead of the demo sequence
+func runCommand(network *client.Network, chaincodeName string, args []string) {
+	switch args[0] {
+	case "listen":
+		listen(network, chaincodeName, args[1:])
+	case "evidence":
+		evidenceCommand(network.GetContractWithName(chaincodeName, "evidence"), args[1:])
+	case "diary":
+		diaryCommand(network.GetContract(chaincodeName), args[1:])
+	case "party":
+		partyCommand(network.GetContract(chaincodeName), args[1:])
+	case "arrest":
+		arrestCommand(network.GetContract(chaincodeName), args[1:])
+	case "warrant":
+		warrantCommand(network.GetContract(chaincodeName), args[1:])
+	case "bail":
+		bailCommand(network.GetContract(chaincodeName), args[1:])
+	case "report":
+		reportCommand(network.GetContract(chaincodeName), args[1:])
+	case "transfer":
+		transferCommand(network.GetContract(chaincodeName), args[1:])
+	case "verify-receipt":
+		if len(args) != 2 {
+			usage()
+			os.Exit(2)
+		}
+		verifyReceipt(network, network.GetContract(chaincodeName), args[1])
+	case "seal":
+		if len(args) != 5 {
+			usage()
+			os.Exit(2)
+		}
+		sealFIR(network.GetContract(chaincodeName), args[1], args[2], args[3], args[4])
+	case "read-sealed":
+		if len(args) != 3 {
+			usage()
+			os.Exit(2)
+		}
+		readSealedFIR(network.GetContract(chaincodeName), args[1], args[2])
+	case "assign-io", "reassign-io":
+		if len(args) != 4 {
+			usage()
+			os.Exit(2)
+		}
+		assignIO(network.GetContract(chaincodeName), args[0] == "reassign-io", args[1], args[2], args[3])
+	case "caseload":
+		if len(args) != 2 {
+			usage()
+			os.Exit(2)
+		}
+		getFIRsByIO(network.GetContract(chaincodeName), args[1])
+	case "public-view":
+		if len(args) != 2 {
+			usage()
+			os.Exit(2)
+		}
+		publicFIRView(network.GetContract(chaincodeName), args[1])
+	case "purge":
+		if len(args) != 2 {
+			usage()
+			os.Exit(2)
+		}
+		purgeFIR(network.GetContract(chaincodeName), args[1])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: go run . [command]
+
+With no command, runs the FIR demo sequence, which writes a signed receipt for the
+FIR it files.
+
+Commands:
+  listen [startBlock]
+  evidence register <evidenceID> <firID> <file> <collectedByOfficerID> <collectedAtRFC3339> [mediaType] [description]
+  evidence verify <evidenceID> <file>
+  evidence transfer <evidenceID> <toHolder> <Org1MSP|Org2MSP> <Officer|ForensicLab|Malkhana|Court> <reason>
+  evidence accept <evidenceID>
+  evidence cancel <evidenceID> <reason>
+  evidence reject <evidenceID> <reason>
+  evidence custody <evidenceID>
+  diary add <firID> <text> [evidenceID...]
+  diary correct <firID> <entry> <text> [evidenceID...]
+  diary show <firID>
+  party add <firID> <partyJSON>
+  party update <firID> <partyNo> <partyJSON>
+  arrest record <firID> <partyNo> <place> <groundsCommunicated>
+  arrest produce <firID> <arrestNo> <court> [remarks]
+  arrest remand <firID> <arrestNo> <Police|Judicial> <remandUntil> <orderRef>
+  arrest release <firID> <arrestNo> <reason> [orderRef]
+  arrest list <firID>
+  arrest overdue
+  warrant execute <warrantID> <Arrested|Served|ReturnedUnexecuted> <remarks> [arrestNo]
+  warrant show <warrantID>
+  warrant outstanding station <station>
+  warrant outstanding person <personIDOrName>
+  bail execute <bailID> <remarks>
+  bail show <bailID>
+  report chargesheet <firID> <sectionsJSON> <accusedPartyNosJSON> [witnessPartyNosJSON] [evidenceIDsJSON]
+  report closure <firID> <reasons>
+  report show <firID>
+  transfer request <firID> <toStation> <reason>
+  transfer accept <firID>
+  transfer reject <firID> <reason>
+  transfer trail <firID>
+  verify-receipt <receiptFile>
+  seal <firID> <Withdrawn|Expunged> <reason> <orderRef>
+  read-sealed <firID> <purpose>
+  public-view <firID>
+  purge <firID>
+  assign-io <firID> <officerID> <reason>
+  reassign-io <firID> <officerID> <reason>
+  caseload <officerID>`)
+}
+
 func newGrpcConnection() *grpc.ClientConn {
 	certificatePEM, err := os.ReadFile(tlsCertPath)
 	if err != nil {
@@ -155,13 +293,24 @@ func readFirstFile(dirPath string) ([]byte, error) {
 	return os.ReadFile(path.Join(dirPath, fileNames[0]))
 }
 
-func initLedger(contract *client.Contract) {
+// initLedger seeds the ledger, or finds the seed FIRs of an earlier run, and returns
+// their numbers
+func initLedger(contract *client.Contract) []string {
 	fmt.Println("\n--> Submit Transaction: InitLedger")
-	_, err := contract.SubmitTransaction("InitLedger")
+	result, err := contract.SubmitTransaction("InitLedger")
 	if err != nil {
 		panic(fmt.Errorf("failed to submit InitLedger: %w", err))
 	}
-	fmt.Println("*** InitLedger committed successfully")
+
+	var firIDs []string
+	if err := json.Unmarshal(result, &firIDs); err != nil {
+		panic(fmt.Errorf("failed to parse InitLedger result: %w", err))
+	}
+	if len(firIDs) == 0 {
+		panic(fmt.Errorf("InitLedger returned no FIR numbers"))
+	}
+	fmt.Printf("*** InitLedger committed successfully, seed FIRs %v\n", firIDs)
+	return firIDs
 }
 
 func getAllFIRs(contract *client.Contract) {
@@ -173,17 +322,94 @@ func getAllFIRs(contract *client.Contract) {
 	fmt.Printf("*** Result: %s\n", formatJSON(result))
 }
 
-func createFIR(contract *client.Contract) {
+func listAllFIRs(contract *client.Contract) {
+	fmt.Println("\n--> Evaluate Transaction: GetFIRsWithPagination, walking every page")
+	count := 0
+	for fir, err := range allRecords(contract, "GetFIRsWithPagination", 10) {
+		if err != nil {
+			panic(err)
+		}
+		count++
+		fmt.Printf("*** [%d] %s\n", count, fir)
+	}
+	fmt.Printf("*** %d FIRs in total\n", count)
+}
+
+// recordPage mirrors the paginated query result returned by the chaincode
+type recordPage struct {
+	Records             []json.RawMessage `json:"records"`
+	FetchedRecordsCount int32             `json:"fetchedRecordsCount"`
+	Bookmark            string            `json:"bookmark"`
+}
+
+// allRecords walks every page of a paginated chaincode query, fetching the next page only
+// when the caller has consumed the current one.
+func allRecords(contract *client.Contract, transactionName string, pageSize int32) iter.Seq2[json.RawMessage, error] {
+	return func(yield func(json.RawMessage, error) bool) {
+		bookmark := ""
+		for {
+			result, err := contract.EvaluateTransaction(transactionName, strconv.Itoa(int(pageSize)), bookmark)
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to evaluate %s: %w", transactionName, err))
+				return
+			}
+
+			var page recordPage
+			if err := json.Unmarshal(result, &page); err != nil {
+				yield(nil, fmt.Errorf("failed to parse %s page: %w", transactionName, err))
+				return
+			}
+
+			for _, record := range page.Records {
+				if !yield(record, nil) {
+					return
+				}
+			}
+
+			if page.Bookmark == "" || page.Bookmark == bookmark || page.FetchedRecordsCount < pageSize {
+				return
+			}
+			bookmark = page.Bookmark
+		}
+	}
+}
+
+// createFIR files a new FIR and returns the number the chaincode allocated to it
+func createFIR(contract *client.Contract) string {
 	fmt.Printf("\n--> Submit Transaction: CreateFIR, creates a new FIR record\n")
 
-	_, err := contract.SubmitTransaction("FileFIR",
-		"FIR3",
-		"OfficerC",
-		"Robbery",
-		"Bank robbery at downtown",
-		"Alex Murphy",
-		"Open",
-		"2024-01-03T12:00:00Z",
+	// Complainant, victim and witness details travel in the transient map so they are
+	// never written to the public ledger.
+	privateDetails, err := json.Marshal(map[string]interface{}{
+		"Parties": []map[string]interface{}{
+			{"Role": "Complainant", "Name": "Priya Nair", "Age": 34, "Gender": "Female", "Address": "Flat 12, Shanti Niwas, Dadar, Mumbai"},
+			{"Role": "Victim", "Name": "Downtown Co-operative Bank", "Address": "Fort, Mumbai"},
+			{"Role": "Witness", "Name": "R. Sharma", "Gender": "Male"},
+			{"Role": "Witness", "Name": "K. Iyer", "Gender": "Female"},
+		},
+		"ContactDetails": "+91-98200-00000",
+		"Statement":      "Two masked men entered the branch at 11:40 and took cash from the counter.",
+	})
+	if err != nil {
+		panic(fmt.Errorf("failed to encode private details: %w", err))
+	}
+
+	// Accused are public; the second robber has not been identified yet
+	accused, err := json.Marshal([]map[string]interface{}{
+		{"Role": "Accused", "Name": "Alex Murphy", "Aliases": []string{"Murph"}, "Age": 29, "Gender": "Male"},
+		{"Role": "Accused", "Unknown": true, "Gender": "Male"},
+	})
+	if err != nil {
+		panic(fmt.Errorf("failed to encode accused: %w", err))
+	}
+
+	firID, commitStatus, err := submitWithRetry(contract, "FileFIR",
+		client.WithArguments(
+			string(accused),
+			"Robbery",
+			"Bank robbery at downtown",
+		),
+		client.WithTransient(map[string][]byte{"fir_private": privateDetails, "salt": newSalt()}),
 	)
 
 	if err != nil {
@@ -204,26 +430,185 @@ func createFIR(contract *client.Contract) {
 	}
 
 	fmt.Printf("*** FIR created successfully\n")
+	issueReceipt(contract, string(firID), commitStatus)
+	return string(firID)
 }
 
-func readFIRByID(contract *client.Contract) {
-	fmt.Println("\n--> Evaluate Transaction: ReadFIR")
-	result, err := contract.EvaluateTransaction("ReadFIR", "FIR1")
+// submitWithRetry submits a transaction and resubmits it if it fails validation because
+// a concurrent transaction changed the keys it read. FileFIR hits this when two FIRs are
+// filed at the same station at once; the resubmitted filing is allocated the next number.
+// It returns the commit status of the successful submission alongside the result.
+func submitWithRetry(contract *client.Contract, transactionName string, options ...client.ProposalOption) ([]byte, *client.Status, error) {
+	for attempt := 1; ; attempt++ {
+		result, commit, err := contract.SubmitAsync(transactionName, options...)
+		if err != nil {
+			return nil, nil, err
+		}
+		commitStatus, err := commit.Status()
+		if err != nil {
+			return nil, nil, err
+		}
+		if commitStatus.Successful {
+			return result, commitStatus, nil
+		}
+
+		if attempt == maxSubmitAttempts || (commitStatus.Code != peer.TxValidationCode_MVCC_READ_CONFLICT && commitStatus.Code != peer.TxValidationCode_PHANTOM_READ_CONFLICT) {
+			return nil, commitStatus, fmt.Errorf("transaction %s failed to commit with status code %d (%s)", commitStatus.TransactionID, int32(commitStatus.Code), commitStatus.Code)
+		}
+
+		fmt.Printf("*** Transaction %s failed with %s, resubmitting (attempt %d of %d)\n", commitStatus.TransactionID, commitStatus.Code, attempt+1, maxSubmitAttempts)
+		time.Sleep(time.Duration(attempt*attempt) * 100 * time.Millisecond)
+	}
+}
+
+func readFIRByID(contract *client.Contract, firID string) {
+	fmt.Printf("\n--> Evaluate Transaction: ReadFIR, returns FIR %s\n", firID)
+	result, err := contract.EvaluateTransaction("ReadFIR", firID)
 	if err != nil {
 		panic(fmt.Errorf("failed to read FIR: %w", err))
 	}
 	fmt.Printf("*** Result: %s\n", formatJSON(result))
 }
 
-func updateFIR(contract *client.Contract) {
-	fmt.Println("\n--> Submit Transaction: UpdateFIR")
-	_, err := contract.SubmitTransaction("UpdateFIR", "FIR1", "Closed")
+func updateFIR(contract *client.Contract, firID string) {
+	fmt.Printf("\n--> Submit Transaction: UpdateFIR, moves FIR %s under investigation\n", firID)
+	_, err := contract.SubmitTransaction("UpdateFIR", firID, "UnderInvestigation")
 	if err != nil {
 		panic(fmt.Errorf("failed to update FIR: %w", err))
 	}
 	fmt.Println("*** FIR updated successfully")
 }
 
+// firHistoryEntry mirrors the chaincode's FIRHistoryEntry
+type firHistoryEntry struct {
+	TxID        string          `json:"TxID"`
+	Timestamp   string          `json:"Timestamp"`
+	MSPID       string          `json:"MSPID"`
+	SubmittedBy string          `json:"SubmittedBy"`
+	IsDelete    bool            `json:"IsDelete"`
+	FIR         json.RawMessage `json:"FIR"`
+	ContentHash string          `json:"ContentHash"`
+}
+
+func getFIRHistory(contract *client.Contract, firID string) {
+	fmt.Println("\n--> Evaluate Transaction: GetFIRHistory")
+	result, err := contract.EvaluateTransaction("GetFIRHistory", firID)
+	if err != nil {
+		panic(fmt.Errorf("failed to evaluate GetFIRHistory: %w", err))
+	}
+
+	var history []firHistoryEntry
+	if err := json.Unmarshal(result, &history); err != nil {
+		panic(fmt.Errorf("failed to parse FIR history: %w", err))
+	}
+
+	fmt.Printf("*** Timeline for %s:\n", firID)
+	for i, entry := range history {
+		action := "updated"
+		if i == 0 {
+			action = "created"
+		}
+		if entry.IsDelete {
+			action = "deleted"
+		}
+		fmt.Printf("[%d] %s %s by %s (%s) in tx %s\n", i+1, entry.Timestamp, action, entry.SubmittedBy, entry.MSPID, entry.TxID)
+		if len(entry.FIR) > 0 {
+			fmt.Printf("    %s\n", entry.FIR)
+		}
+	}
+}
+
+func sealFIR(contract *client.Contract, firID, disposition, reason, orderRef string) {
+	fmt.Printf("\n--> Submit Transaction: SealFIR, marks %s as %s\n", firID, disposition)
+	_, err := contract.SubmitTransaction("SealFIR", firID, disposition, reason, orderRef)
+	if err != nil {
+		panic(fmt.Errorf("failed to seal FIR: %w", err))
+	}
+	fmt.Println("*** FIR sealed successfully")
+}
+
+// publicFIRView shows the redacted view of a FIR available to the citizen portal
+func publicFIRView(contract *client.Contract, firID string) {
+	fmt.Println("\n--> Evaluate Transaction: PublicFIRView")
+	result, err := contract.EvaluateTransaction("PublicFIRView", firID)
+	if err != nil {
+		panic(fmt.Errorf("failed to evaluate PublicFIRView: %w", err))
+	}
+	fmt.Printf("*** Result: %s\n", formatJSON(result))
+}
+
+// readSealedFIR submits rather than evaluates so the chaincode's access record is committed
+func readSealedFIR(contract *client.Contract, firID, purpose string) {
+	fmt.Println("\n--> Submit Transaction: ReadSealedFIR")
+	result, err := contract.SubmitTransaction("ReadSealedFIR", firID, purpose)
+	if err != nil {
+		panic(fmt.Errorf("failed to read sealed FIR: %w", err))
+	}
+	fmt.Printf("*** Result: %s\n", formatJSON(result))
+}
+
+// purgeFIR asks both the police and judiciary peers to endorse, as required by the
+// key-level endorsement policy SealFIR places on the FIR
+func purgeFIR(contract *client.Contract, firID string) {
+	fmt.Println("\n--> Submit Transaction: PurgeFIR, endorsed by Org1MSP and Org2MSP")
+	_, err := contract.Submit("PurgeFIR",
+		client.WithArguments(firID),
+		client.WithEndorsingOrganizations("Org1MSP", "Org2MSP"),
+	)
+	if err != nil {
+		panic(fmt.Errorf("failed to purge FIR: %w", err))
+	}
+	fmt.Println("*** FIR purged successfully")
+}
+
+// assignIO assigns or reassigns the Investigating Officer of a FIR
+func assignIO(contract *client.Contract, reassign bool, firID, officerID, reason string) {
+	transactionName := "AssignIO"
+	if reassign {
+		transactionName = "ReassignIO"
+	}
+	fmt.Printf("\n--> Submit Transaction: %s, assigns %s to %s\n", transactionName, firID, officerID)
+	_, err := contract.SubmitTransaction(transactionName, firID, officerID, reason)
+	if err != nil {
+		panic(fmt.Errorf("failed to assign Investigating Officer: %w", err))
+	}
+	fmt.Println("*** Investigating Officer assigned successfully")
+}
+
+func getFIRsByIO(contract *client.Contract, officerID string) {
+	fmt.Printf("\n--> Evaluate Transaction: GetFIRsByIO, returns the caseload of %s\n", officerID)
+	evaluateResult, err := contract.EvaluateTransaction("GetFIRsByIO", officerID)
+	if err != nil {
+		panic(fmt.Errorf("failed to evaluate transaction: %w", err))
+	}
+
+	var caseload struct {
+		OfficerID        string
+		EmploymentStatus string
+		Station          string
+		Cases            []struct {
+			FIR struct {
+				FIRID  string
+				Status string
+			}
+			NeedsReassignment  bool
+			ReassignmentReason string
+		}
+	}
+	if err := json.Unmarshal(evaluateResult, &caseload); err != nil {
+		panic(fmt.Errorf("failed to parse caseload: %w", err))
+	}
+
+	fmt.Printf("*** %s (%s, %s): %d FIR(s)\n", caseload.OfficerID, caseload.EmploymentStatus, caseload.Station, len(caseload.Cases))
+	for _, c := range caseload.Cases {
+		fmt.Printf("    %s %s", c.FIR.FIRID, c.FIR.Status)
+		if c.NeedsReassignment {
+			fmt.Printf("  NEEDS REASSIGNMENT: %s", c.ReassignmentReason)
+		}
+		fmt.Println()
+	}
+}
+
 func exampleErrorHandling(contract *client.Contract) {
 	fmt.Println("\n--> Submit Transaction: UpdateFIR with wrong ID")
 	_, err := contract.SubmitTransaction("UpdateFIR", "NON_EXISTENT_FIR", "Closed")
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// partyCommand adds or updates a party. The party JSON is sent in the transient map so
+// that victim and witness details are not recorded in the transaction.
+func partyCommand(contract *client.Contract, args []string) {
+	switch {
+	case len(args) == 3 && args[0] == "add":
+		fmt.Printf("\n--> Submit Transaction: AddParty, adds a party to %s\n", args[1])
+		result, err := contract.Submit("AddParty",
+			client.WithArguments(args[1]),
+			client.WithTransient(map[string][]byte{"party": []byte(args[2]), "salt": newSalt()}),
+		)
+		if err != nil {
+			panic(fmt.Errorf("failed to add party: %w", err))
+		}
+		fmt.Printf("*** Party %s added\n", result)
+	case len(args) == 4 && args[0] == "update":
+		if _, err := strconv.Atoi(args[2]); err != nil {
+			panic(fmt.Errorf("invalid party number %q: %w", args[2], err))
+		}
+		fmt.Printf("\n--> Submit Transaction: UpdateParty, updates party %s of %s\n", args[2], args[1])
+		_, err := contract.Submit("UpdateParty",
+			client.WithArguments(args[1], args[2]),
+			client.WithTransient(map[string][]byte{"party": []byte(args[3]), "salt": newSalt()}),
+		)
+		if err != nil {
+			panic(fmt.Errorf("failed to update party: %w", err))
+		}
+		fmt.Println("*** Party updated successfully")
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+// newSalt returns a random salt for the private records a transaction writes. The chaincode
+// stores it with the record so its hash on the ledger cannot be matched against guessed details.
+func newSalt() []byte {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		panic(fmt.Errorf("failed to generate salt: %w", err))
+	}
+	return []byte(hex.EncodeToString(salt))
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"google.golang.org/protobuf/proto"
+)
+
+// fabricCAAttrsOID is the certificate extension in which Fabric CA records enrollment attributes
+var fabricCAAttrsOID = asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}
+
+// receiptContent is the part of a filing receipt covered by the station's signature
+type receiptContent struct {
+	FIRID         string `json:"FIRID"`
+	Station       string `json:"Station"`
+	TransactionID string `json:"TransactionID"`
+	BlockNumber   uint64 `json:"BlockNumber"`
+
+	// CommittedAt is the transaction timestamp the ledger records for the filing
+	CommittedAt string `json:"CommittedAt"`
+
+	// ContentHash is the hex SHA-256 of the FIR as written by the filing transaction
+	ContentHash string `json:"ContentHash"`
+}
+
+// filingReceipt is the acknowledgement handed to a complainant once a FIR is committed
+type filingReceipt struct {
+	receiptContent
+	SignerMSP         string `json:"SignerMSP"`
+	SignerCertificate string `json:"SignerCertificate"`
+	Signature         string `json:"Signature"`
+}
+
+// digest returns the SHA-256 of the signed part of the receipt
+func (r *receiptContent) digest() ([]byte, error) {
+	contentJSON, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(contentJSON)
+	return hash[:], nil
+}
+
+// receiptFileName returns the file a receipt for a FIR is written to
+func receiptFileName(firID string) string {
+	return "receipt-" + strings.ReplaceAll(firID, "/", "-") + ".json"
+}
+
+// findHistoryEntry returns the version of a FIR written by a transaction
+func findHistoryEntry(contract *client.Contract, firID, txID string) (*firHistoryEntry, bool, error) {
+	result, err := contract.EvaluateTransaction("GetFIRHistory", firID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to evaluate GetFIRHistory: %w", err)
+	}
+
+	var history []firHistoryEntry
+	if err := json.Unmarshal(result, &history); err != nil {
+		return nil, false, fmt.Errorf("failed to parse FIR history: %w", err)
+	}
+	for i := range history {
+		if history[i].TxID == txID {
+			return &history[i], i == len(history)-1, nil
+		}
+	}
+	return nil, false, fmt.Errorf("transaction %s did not write FIR %s", txID, firID)
+}
+
+// issueReceipt writes a receipt for a committed FileFIR transaction, signed with the
+// station's key. STATION_CERT_PATH and STATION_KEY_PATH name the directories holding the
+// station's signing certificate and key; they default to the gateway's own identity.
+func issueReceipt(contract *client.Contract, firID string, commitStatus *client.Status) {
+	fmt.Printf("\n--> Evaluate Transaction: GetFIRHistory, builds the filing receipt for %s\n", firID)
+
+	entry, _, err := findHistoryEntry(contract, firID, commitStatus.TransactionID)
+	if err != nil {
+		panic(err)
+	}
+	var fir struct {
+		Station string `json:"Station"`
+	}
+	if err := json.Unmarshal(entry.FIR, &fir); err != nil {
+		panic(fmt.Errorf("failed to parse FIR: %w", err))
+	}
+
+	stationCertPath := certPath
+	if p := os.Getenv("STATION_CERT_PATH"); p != "" {
+		stationCertPath = p
+	}
+	stationKeyPath := keyPath
+	if p := os.Getenv("STATION_KEY_PATH"); p != "" {
+		stationKeyPath = p
+	}
+	certificatePEM, err := readFirstFile(stationCertPath)
+	if err != nil {
+		panic(fmt.Errorf("failed to read station certificate file: %w", err))
+	}
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		panic(err)
+	}
+	// Verification refuses receipts not signed for the FIR's own station, so do not issue one
+	if station, err := certificateStation(certificate); err != nil || station != fir.Station {
+		panic(fmt.Errorf("the station certificate must carry the station attribute %s to sign receipts for FIR %s", fir.Station, firID))
+	}
+	privateKeyPEM, err := readFirstFile(stationKeyPath)
+	if err != nil {
+		panic(fmt.Errorf("failed to read station private key file: %w", err))
+	}
+	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
+	if err != nil {
+		panic(err)
+	}
+	sign, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		panic(err)
+	}
+
+	receipt := filingReceipt{
+		receiptContent: receiptContent{
+			FIRID:         firID,
+			Station:       fir.Station,
+			TransactionID: commitStatus.TransactionID,
+			BlockNumber:   commitStatus.BlockNumber,
+			CommittedAt:   entry.Timestamp,
+			ContentHash:   entry.ContentHash,
+		},
+		SignerMSP:         mspID,
+		SignerCertificate: string(certificatePEM),
+	}
+	digest, err := receipt.digest()
+	if err != nil {
+		panic(err)
+	}
+	signature, err := sign(digest)
+	if err != nil {
+		panic(fmt.Errorf("failed to sign receipt: %w", err))
+	}
+	receipt.Signature = base64.StdEncoding.EncodeToString(signature)
+
+	receiptJSON, err := json.MarshalIndent(receipt, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	fileName := receiptFileName(firID)
+	if err := os.WriteFile(fileName, receiptJSON, 0o644); err != nil {
+		panic(fmt.Errorf("failed to write receipt: %w", err))
+	}
+	fmt.Printf("*** Receipt for %s (tx %s, block %d) written to %s\n", firID, receipt.TransactionID, receipt.BlockNumber, fileName)
+}
+
+// verifyReceipt checks a receipt's signature against the organisation CA, then confirms
+// against the ledger that its transaction wrote the FIR it describes, in the block it
+// names, with the content hash it carries
+func verifyReceipt(network *client.Network, contract *client.Contract, fileName string) {
+	fmt.Printf("\n--> Verifying receipt %s\n", fileName)
+
+	receiptJSON, err := os.ReadFile(fileName)
+	if err != nil {
+		panic(fmt.Errorf("failed to read receipt: %w", err))
+	}
+	var receipt filingReceipt
+	if err := json.Unmarshal(receiptJSON, &receipt); err != nil {
+		panic(fmt.Errorf("failed to parse receipt: %w", err))
+	}
+
+	station, err := verifyReceiptSignature(&receipt)
+	if err != nil {
+		fmt.Printf("*** Receipt signature is INVALID: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("*** Receipt signature is valid, signed for station %s\n", station)
+
+	fmt.Printf("\n--> Evaluate Transaction: GetBlockByTxID, locates transaction %s\n", receipt.TransactionID)
+	blockNumber, err := getBlockNumberByTxID(network, receipt.TransactionID)
+	if err != nil {
+		panic(err)
+	}
+	if blockNumber != receipt.BlockNumber {
+		fmt.Printf("*** Receipt does NOT match the ledger: transaction %s is in block %d, not %d\n", receipt.TransactionID, blockNumber, receipt.BlockNumber)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n--> Evaluate Transaction: GetFIRHistory, compares %s as written by %s\n", receipt.FIRID, receipt.TransactionID)
+	entry, current, err := findHistoryEntry(contract, receipt.FIRID, receipt.TransactionID)
+	if err != nil {
+		fmt.Printf("*** Receipt does NOT match the ledger: %v\n", err)
+		os.Exit(1)
+	}
+	if entry.ContentHash != receipt.ContentHash || entry.Timestamp != receipt.CommittedAt {
+		fmt.Printf("*** Receipt does NOT match the ledger: FIR %s as written by %s has hash %s at %s\n", receipt.FIRID, receipt.TransactionID, entry.ContentHash, entry.Timestamp)
+		os.Exit(1)
+	}
+
+	if current {
+		fmt.Printf("*** Receipt matches the current record of FIR %s\n", receipt.FIRID)
+	} else {
+		fmt.Printf("*** Receipt matches the record of FIR %s as filed; it has been updated since\n", receipt.FIRID)
+	}
+}
+
+// verifyReceiptSignature checks that the receipt was signed by a certificate issued by the
+// organisation CA for the station named in the receipt, and returns that station. The
+// certificate must have been valid when the filing was committed, which verifyReceipt then
+// checks against the ledger, and must not have been revoked by the CA. A certificate
+// without a station attribute cannot vouch for any station and is refused.
+func verifyReceiptSignature(receipt *filingReceipt) (string, error) {
+	certificate, err := identity.CertificateFromPEM([]byte(receipt.SignerCertificate))
+	if err != nil {
+		return "", fmt.Errorf("invalid signer certificate: %w", err)
+	}
+	committedAt, err := time.Parse(time.RFC3339Nano, receipt.CommittedAt)
+	if err != nil {
+		return "", fmt.Errorf("invalid commit time %q: %w", receipt.CommittedAt, err)
+	}
+
+	caPEM, err := readFirstFile(caCertPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read CA certificate file: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return "", fmt.Errorf("no CA certificates found in %s", caCertPath)
+	}
+	chains, err := certificate.Verify(x509.VerifyOptions{
+		Roots:       roots,
+		CurrentTime: committedAt,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return "", fmt.Errorf("signer certificate was not valid from the %s CA at %s: %w", mspID, receipt.CommittedAt, err)
+	}
+	if len(chains[0]) < 2 {
+		return "", fmt.Errorf("signer certificate is a CA certificate")
+	}
+	if err := checkRevocation(certificate, chains[0][1]); err != nil {
+		return "", err
+	}
+
+	publicKey, ok := certificate.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return "", fmt.Errorf("unsupported signer key type %T", certificate.PublicKey)
+	}
+	signature, err := base64.StdEncoding.DecodeString(receipt.Signature)
+	if err != nil {
+		return "", fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	digest, err := receipt.digest()
+	if err != nil {
+		return "", err
+	}
+	if !ecdsa.VerifyASN1(publicKey, digest, signature) {
+		return "", fmt.Errorf("signature does not match the receipt content")
+	}
+
+	station, err := certificateStation(certificate)
+	if err != nil {
+		return "", err
+	}
+	if station == "" {
+		return "", fmt.Errorf("signer certificate carries no station attribute")
+	}
+	if station != receipt.Station {
+		return "", fmt.Errorf("signed by a certificate for station %s, not %s", station, receipt.Station)
+	}
+	return station, nil
+}
+
+// checkRevocation refuses a certificate listed in a revocation list signed by its issuer.
+// Without any revocation list it only warns, since a network set up without Fabric CA
+// has none.
+func checkRevocation(certificate, issuer *x509.Certificate) error {
+	fileNames, err := os.ReadDir(crlPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read revocation lists: %w", err)
+	}
+
+	found := false
+	for _, fileName := range fileNames {
+		crlPEM, err := os.ReadFile(path.Join(crlPath, fileName.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read revocation list %s: %w", fileName.Name(), err)
+		}
+		for block, rest := pem.Decode(crlPEM); block != nil; block, rest = pem.Decode(rest) {
+			crl, err := x509.ParseRevocationList(block.Bytes)
+			if err != nil {
+				return fmt.Errorf("invalid revocation list %s: %w", fileName.Name(), err)
+			}
+			if crl.CheckSignatureFrom(issuer) != nil {
+				continue
+			}
+			found = true
+			for _, entry := range crl.RevokedCertificateEntries {
+				if entry.SerialNumber.Cmp(certificate.SerialNumber) == 0 {
+					return fmt.Errorf("signer certificate was revoked at %s", entry.RevocationTime.UTC().Format(time.RFC3339))
+				}
+			}
+		}
+	}
+	if !found {
+		fmt.Printf("*** Warning: no revocation list from the signer's CA in %s; revocation was not checked\n", crlPath)
+	}
+	return nil
+}
+
+// certificateStation returns the station attribute Fabric CA recorded in a certificate,
+// or an empty string if it has none
+func certificateStation(certificate *x509.Certificate) (string, error) {
+	for _, extension := range certificate.Extensions {
+		if !extension.Id.Equal(fabricCAAttrsOID) {
+			continue
+		}
+		var attrs struct {
+			Attrs map[string]string `json:"attrs"`
+		}
+		if err := json.Unmarshal(extension.Value, &attrs); err != nil {
+			return "", fmt.Errorf("invalid certificate attributes: %w", err)
+		}
+		return attrs.Attrs["station"], nil
+	}
+	return "", nil
+}
+
+// getBlockNumberByTxID asks the query system chaincode for the block holding a transaction
+func getBlockNumberByTxID(network *client.Network, txID string) (uint64, error) {
+	result, err := network.GetContract("qscc").EvaluateTransaction("GetBlockByTxID", network.Name(), txID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to evaluate GetBlockByTxID: %w", err)
+	}
+
+	var block common.Block
+	if err := proto.Unmarshal(result, &block); err != nil {
+		return 0, fmt.Errorf("failed to parse block: %w", err)
+	}
+	return block.GetHeader().GetNumber(), nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// reportCommand submits a chargesheet or closure report on a FIR, or shows its final reports
+func reportCommand(contract *client.Contract, args []string) {
+	switch {
+	case len(args) >= 4 && len(args) <= 6 && args[0] == "chargesheet":
+		witnessesJSON, evidenceRefsJSON := "", ""
+		if len(args) > 4 {
+			witnessesJSON = args[4]
+		}
+		if len(args) > 5 {
+			evidenceRefsJSON = args[5]
+		}
+		fmt.Printf("\n--> Submit Transaction: SubmitChargesheet, files a chargesheet on %s\n", args[1])
+		result, err := contract.SubmitTransaction("SubmitChargesheet", args[1], args[2], args[3], witnessesJSON, evidenceRefsJSON)
+		if err != nil {
+			panic(fmt.Errorf("failed to submit chargesheet: %w", err))
+		}
+		fmt.Printf("*** Chargesheet submitted as final report %s\n", result)
+	case len(args) == 3 && args[0] == "closure":
+		fmt.Printf("\n--> Submit Transaction: SubmitClosureReport, files a closure report on %s\n", args[1])
+		result, err := contract.SubmitTransaction("SubmitClosureReport", args[1], args[2])
+		if err != nil {
+			panic(fmt.Errorf("failed to submit closure report: %w", err))
+		}
+		fmt.Printf("*** Closure report submitted as final report %s\n", result)
+	case len(args) == 2 && args[0] == "show":
+		fmt.Printf("\n--> Evaluate Transaction: GetFinalReports, returns the final reports on %s\n", args[1])
+		result, err := contract.EvaluateTransaction("GetFinalReports", args[1])
+		if err != nil {
+			panic(fmt.Errorf("failed to evaluate transaction: %w", err))
+		}
+		fmt.Printf("*** Result:%s\n", formatJSON(result))
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
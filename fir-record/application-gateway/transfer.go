@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// transferCommand requests, accepts or rejects the transfer of a Zero FIR to another
+// station, or shows its transfer trail
+func transferCommand(contract *client.Contract, args []string) {
+	switch {
+	case len(args) == 4 && args[0] == "request":
+		fmt.Printf("\n--> Submit Transaction: RequestTransfer, asks %s to take over %s\n", args[2], args[1])
+		_, err := contract.SubmitTransaction("RequestTransfer", args[1], args[2], args[3])
+		if err != nil {
+			panic(fmt.Errorf("failed to request transfer: %w", err))
+		}
+		fmt.Println("*** Transfer requested successfully")
+	case len(args) == 2 && args[0] == "accept":
+		fmt.Printf("\n--> Submit Transaction: AcceptTransfer, registers %s at this station\n", args[1])
+		result, err := contract.SubmitTransaction("AcceptTransfer", args[1])
+		if err != nil {
+			panic(fmt.Errorf("failed to accept transfer: %w", err))
+		}
+		fmt.Printf("*** %s registered as FIR number %s\n", args[1], result)
+	case len(args) == 3 && args[0] == "reject":
+		fmt.Printf("\n--> Submit Transaction: RejectTransfer, declines the transfer of %s\n", args[1])
+		_, err := contract.SubmitTransaction("RejectTransfer", args[1], args[2])
+		if err != nil {
+			panic(fmt.Errorf("failed to reject transfer: %w", err))
+		}
+		fmt.Println("*** Transfer rejected successfully")
+	case len(args) == 2 && args[0] == "trail":
+		fmt.Printf("\n--> Evaluate Transaction: GetTransferTrail, returns the station transfers of %s\n", args[1])
+		result, err := contract.EvaluateTransaction("GetTransferTrail", args[1])
+		if err != nil {
+			panic(fmt.Errorf("failed to evaluate transaction: %w", err))
+		}
+		fmt.Printf("*** Result:%s\n", formatJSON(result))
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
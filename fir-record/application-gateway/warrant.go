@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// warrantCommand records the police execution of a warrant or bail order, or shows them
+// and the outstanding warrants for a station or person
+func warrantCommand(contract *client.Contract, args []string) {
+	switch {
+	case (len(args) == 4 || len(args) == 5) && args[0] == "execute":
+		arrestNo := "0"
+		if len(args) == 5 {
+			arrestNo = args[4]
+			parseNumber("arrest", arrestNo)
+		}
+		fmt.Printf("\n--> Submit Transaction: ExecuteWarrant, records %s as %s\n", args[1], args[2])
+		_, err := contract.SubmitTransaction("ExecuteWarrant", args[1], args[2], args[3], arrestNo)
+		if err != nil {
+			panic(fmt.Errorf("failed to execute warrant: %w", err))
+		}
+		fmt.Println("*** Warrant execution recorded successfully")
+	case len(args) == 2 && args[0] == "show":
+		evaluateAndPrint(contract, "ReadWarrant", args[1])
+	case len(args) == 3 && args[0] == "outstanding" && args[1] == "station":
+		evaluateAndPrint(contract, "QueryOutstandingWarrantsByStation", args[2])
+	case len(args) == 3 && args[0] == "outstanding" && args[1] == "person":
+		evaluateAndPrint(contract, "QueryOutstandingWarrantsByPerson", args[2])
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func bailCommand(contract *client.Contract, args []string) {
+	switch {
+	case len(args) == 3 && args[0] == "execute":
+		fmt.Printf("\n--> Submit Transaction: ExecuteBail, records release on bail order %s\n", args[1])
+		_, err := contract.SubmitTransaction("ExecuteBail", args[1], args[2])
+		if err != nil {
+			panic(fmt.Errorf("failed to execute bail order: %w", err))
+		}
+		fmt.Println("*** Release on bail recorded successfully")
+	case len(args) == 2 && args[0] == "show":
+		evaluateAndPrint(contract, "ReadBailOrder", args[1])
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func evaluateAndPrint(contract *client.Contract, transactionName string, args ...string) {
+	fmt.Printf("\n--> Evaluate Transaction: %s\n", transactionName)
+	result, err := contract.EvaluateTransaction(transactionName, args...)
+	if err != nil {
+		panic(fmt.Errorf("failed to evaluate %s: %w", transactionName, err))
+	}
+	fmt.Printf("*** Result: %s\n", formatJSON(result))
+}
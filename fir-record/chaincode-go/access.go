@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Police roles carried in the "role" attribute of Fabric CA enrollment certificates
+const (
+	RoleSHO       = "SHO"
+	RoleIO        = "IO"
+	RoleConstable = "Constable"
+)
+
+// judiciaryMSP is the MSP ID of the judiciary organisation (Org2)
+const judiciaryMSP = "Org2MSP"
+
+// citizenPortalMSP is the MSP ID of the citizen portal organisation (Org3, added with addOrg3)
+const citizenPortalMSP = "Org3MSP"
+
+// citizenPortalFunctions lists the only transactions the citizen portal may call; see publicview.go
+var citizenPortalFunctions = map[string]bool{
+	"PublicFIRView": true,
+}
+
+// Certificate attributes used for access control
+const (
+	attrRole      = "role"
+	attrStation   = "station"
+	attrOfficerID = "officerId"
+)
+
+// policeRoles lists every role that may act on FIRs
+var policeRoles = []string{RoleSHO, RoleIO, RoleConstable}
+
+// getAttribute returns a required attribute from the caller's certificate
+func getAttribute(ctx contractapi.TransactionContextInterface, name string) (string, error) {
+	value, found, err := ctx.GetClientIdentity().GetAttributeValue(name)
+	if err != nil {
+		return "", fmt.Errorf("unable to read %s attribute: %v", name, err)
+	}
+	if !found || value == "" {
+		return "", fmt.Errorf("access denied: certificate has no %s attribute", name)
+	}
+	return value, nil
+}
+
+// onlyJudiciary enforces access for Org2MSP (Judiciary)
+func onlyJudiciary(ctx contractapi.TransactionContextInterface) error {
+	mspid, err := getMSPID(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get MSP ID: %v", err)
+	}
+	if mspid != judiciaryMSP {
+		return fmt.Errorf("access denied: only Org2 (Judiciary) can perform this operation")
+	}
+	return nil
+}
+
+// getOfficerID returns the caller's officer ID from the officerId certificate attribute,
+// falling back to the certificate common name (the Fabric CA enrollment ID)
+func getOfficerID(ctx contractapi.TransactionContextInterface) (string, error) {
+	officerID, found, err := ctx.GetClientIdentity().GetAttributeValue(attrOfficerID)
+	if err != nil {
+		return "", fmt.Errorf("unable to read %s attribute: %v", attrOfficerID, err)
+	}
+	if found && officerID != "" {
+		return officerID, nil
+	}
+	_, submitter, err := getSubmitter(ctx)
+	return submitter, err
+}
+
+// requireRole enforces that the caller is a police identity whose role attribute is one of roles
+func requireRole(ctx contractapi.TransactionContextInterface, roles ...string) error {
+	if err := onlyPolice(ctx); err != nil {
+		return err
+	}
+	role, err := getAttribute(ctx, attrRole)
+	if err != nil {
+		return fmt.Errorf("%v; requires %s=%s", err, attrRole, strings.Join(roles, "|"))
+	}
+	for _, allowed := range roles {
+		if role == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("access denied: requires %s=%s, certificate has %s=%s", attrRole, strings.Join(roles, "|"), attrRole, role)
+}
+
+// requireStation enforces that the caller's station attribute matches station
+func requireStation(ctx contractapi.TransactionContextInterface, station string) error {
+	callerStation, err := getAttribute(ctx, attrStation)
+	if err != nil {
+		return fmt.Errorf("%v; requires %s=%s", err, attrStation, station)
+	}
+	if callerStation != station {
+		return fmt.Errorf("access denied: requires %s=%s, certificate has %s=%s", attrStation, station, attrStation, callerStation)
+	}
+	return nil
+}
+
+// requireStationRole enforces that the caller holds one of roles at the given station
+func requireStationRole(ctx contractapi.TransactionContextInterface, station string, roles ...string) error {
+	if err := requireRole(ctx, roles...); err != nil {
+		return err
+	}
+	return requireStation(ctx, station)
+}
+
+// restrictCitizenPortal runs before every transaction and limits the citizen portal to
+// citizenPortalFunctions, so it never sees more of a FIR than the redacted public view
+func restrictCitizenPortal(ctx contractapi.TransactionContextInterface) error {
+	mspid, err := getMSPID(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get MSP ID: %v", err)
+	}
+	if mspid != citizenPortalMSP {
+		return nil
+	}
+	function, _ := ctx.GetStub().GetFunctionAndParameters()
+	function = function[strings.LastIndex(function, ":")+1:]
+	if !citizenPortalFunctions[function] {
+		return fmt.Errorf("access denied: Org3 (Citizen portal) may only call PublicFIRView")
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Arrest states
+const (
+	ArrestInCustody = "InCustody"
+	ArrestProduced  = "Produced"
+	ArrestRemanded  = "Remanded"
+	ArrestReleased  = "Released"
+)
+
+// Custody a magistrate may remand an arrestee to
+const (
+	RemandPolice   = "Police"
+	RemandJudicial = "Judicial"
+)
+
+const (
+	arrestDocType   = "arrest"
+	arrestKeyPrefix = "arrest"
+
+	// productionWindow is the time within which an arrestee must be produced before a magistrate
+	productionWindow = 24 * time.Hour
+)
+
+// ArrestEvent records a production, remand or release of an arrestee
+type ArrestEvent struct {
+	Status      string `json:"Status"`
+	RecordedBy  string `json:"RecordedBy"`
+	RecordedMSP string `json:"RecordedMSP"`
+	RecordedAt  string `json:"RecordedAt"`
+	TxID        string `json:"TxID"`
+
+	Court       string `json:"Court,omitempty" metadata:",optional"`
+	OrderRef    string `json:"OrderRef,omitempty" metadata:",optional"`
+	CustodyType string `json:"CustodyType,omitempty" metadata:",optional"`
+	RemandUntil string `json:"RemandUntil,omitempty" metadata:",optional"`
+	Remarks     string `json:"Remarks,omitempty" metadata:",optional"`
+}
+
+// Arrest records the arrest of an accused under a FIR and what has happened to them since
+type Arrest struct {
+	DocType  string `json:"DocType"`
+	FIRID    string `json:"FIRID"`
+	ArrestNo int    `json:"ArrestNo"`
+	Station  string `json:"Station"`
+	Status   string `json:"Status"`
+
+	// PartyNo is the accused party arrested; see parties.go
+	PartyNo    int    `json:"PartyNo"`
+	PersonName string `json:"PersonName"`
+
+	ArrestedBy          string `json:"ArrestedBy"`
+	ArrestedAt          string `json:"ArrestedAt"`
+	Place               string `json:"Place"`
+	GroundsCommunicated string `json:"GroundsCommunicated"`
+	TxID                string `json:"TxID"`
+
+	// ProductionDeadline is 24 hours after the arrest. ProducedAt is the first production
+	// before a magistrate, and ProducedLate is set if it came after the deadline.
+	ProductionDeadline string `json:"ProductionDeadline"`
+	ProducedAt         string `json:"ProducedAt,omitempty" metadata:",optional"`
+	ProducedLate       bool   `json:"ProducedLate"`
+
+	Events []ArrestEvent `json:"Events,omitempty" metadata:",optional"`
+}
+
+// arrestKey returns the world state key for an arrest under a FIR
+func arrestKey(ctx contractapi.TransactionContextInterface, firID string, arrestNo int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(arrestKeyPrefix, []string{firID, fmt.Sprintf("%06d", arrestNo)})
+}
+
+func readArrest(ctx contractapi.TransactionContextInterface, firID string, arrestNo int) (*Arrest, error) {
+	key, err := arrestKey(ctx, firID, arrestNo)
+	if err != nil {
+		return nil, err
+	}
+	arrestJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if arrestJSON == nil {
+		return nil, fmt.Errorf("arrest %d under FIR %s does not exist", arrestNo, firID)
+	}
+
+	var arrest Arrest
+	err = json.Unmarshal(arrestJSON, &arrest)
+	if err != nil {
+		return nil, err
+	}
+	return &arrest, nil
+}
+
+func putArrest(ctx contractapi.TransactionContextInterface, arrest *Arrest) error {
+	key, err := arrestKey(ctx, arrest.FIRID, arrest.ArrestNo)
+	if err != nil {
+		return err
+	}
+	arrest.DocType = arrestDocType
+
+	arrestJSON, err := json.Marshal(arrest)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, arrestJSON)
+}
+
+// RecordArrest records the arrest of an accused party of a FIR and returns the arrest
+// number. The arresting officer and time are taken from the submitting identity and the
+// transaction timestamp; the officer must be active and posted to the FIR's station.
+// groundsCommunicated records the grounds of arrest as communicated to the arrestee.
+func (s *SmartContract) RecordArrest(ctx contractapi.TransactionContextInterface, firID string, partyNo int, place, groundsCommunicated string) (int, error) {
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return 0, err
+	}
+	if fir.TransferredTo != "" {
+		return 0, fmt.Errorf("the FIR %s has been transferred and is now %s", firID, fir.TransferredTo)
+	}
+	if err := requireStationRole(ctx, fir.Station, policeRoles...); err != nil {
+		return 0, err
+	}
+	arrestedBy, err := getOfficerID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := validateOfficer(ctx, arrestedBy, fir.Station); err != nil {
+		return 0, err
+	}
+	if place == "" {
+		return 0, fmt.Errorf("the place of arrest is required")
+	}
+	if groundsCommunicated == "" {
+		return 0, fmt.Errorf("the grounds of arrest communicated to the arrestee are required")
+	}
+
+	var accused *Party
+	for i := range fir.Parties {
+		if fir.Parties[i].PartyNo == partyNo && fir.Parties[i].Role == PartyAccused {
+			accused = &fir.Parties[i]
+		}
+	}
+	if accused == nil {
+		return 0, fmt.Errorf("the FIR %s has no accused with party number %d", firID, partyNo)
+	}
+	if accused.Unknown {
+		return 0, fmt.Errorf("accused %d of FIR %s is unidentified; record their details with UpdateParty first", partyNo, firID)
+	}
+
+	arrestedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return 0, err
+	}
+	arrestTime, err := time.Parse(time.RFC3339, arrestedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	fir.ArrestCount++
+	arrest := Arrest{
+		FIRID:               firID,
+		ArrestNo:            fir.ArrestCount,
+		Station:             fir.Station,
+		Status:              ArrestInCustody,
+		PartyNo:             partyNo,
+		PersonName:          accused.Name,
+		ArrestedBy:          arrestedBy,
+		ArrestedAt:          arrestedAt,
+		Place:               place,
+		GroundsCommunicated: groundsCommunicated,
+		TxID:                ctx.GetStub().GetTxID(),
+		ProductionDeadline:  arrestTime.Add(productionWindow).Format(time.RFC3339),
+	}
+	if err := putArrest(ctx, &arrest); err != nil {
+		return 0, err
+	}
+	if err := putFIR(ctx, fir); err != nil {
+		return 0, err
+	}
+	return arrest.ArrestNo, nil
+}
+
+// readArrestForEvent returns an arrest and a new event on it, checking that the caller
+// is the judiciary or the SHO or an IO of the arrest's station
+func readArrestForEvent(ctx contractapi.TransactionContextInterface, firID string, arrestNo int, status string) (*Arrest, *ArrestEvent, error) {
+	arrest, err := readArrest(ctx, firID, arrestNo)
+	if err != nil {
+		return nil, nil, err
+	}
+	if arrest.Status == ArrestReleased {
+		return nil, nil, fmt.Errorf("arrest %d under FIR %s has already been released", arrestNo, firID)
+	}
+
+	mspid, recordedBy, err := getSubmitter(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := onlyJudiciary(ctx); err != nil {
+		if err := requireStationRole(ctx, arrest.Station, RoleSHO, RoleIO); err != nil {
+			return nil, nil, err
+		}
+		recordedBy, err = getOfficerID(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	recordedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	event := ArrestEvent{
+		Status:      status,
+		RecordedBy:  recordedBy,
+		RecordedMSP: mspid,
+		RecordedAt:  recordedAt,
+		TxID:        ctx.GetStub().GetTxID(),
+	}
+	return arrest, &event, nil
+}
+
+// RecordProduction records that an arrestee was produced before a magistrate. The
+// production time is the transaction timestamp. The SHO or an IO of the arrest's station
+// or the judiciary may record it.
+func (s *SmartContract) RecordProduction(ctx contractapi.TransactionContextInterface, firID string, arrestNo int, court, remarks string) error {
+	arrest, event, err := readArrestForEvent(ctx, firID, arrestNo, ArrestProduced)
+	if err != nil {
+		return err
+	}
+	if court == "" {
+		return fmt.Errorf("the court the arrestee was produced before is required")
+	}
+
+	event.Court = court
+	event.Remarks = remarks
+	if arrest.ProducedAt == "" {
+		arrest.ProducedAt = event.RecordedAt
+		arrest.ProducedLate = event.RecordedAt > arrest.ProductionDeadline
+	}
+	arrest.Status = ArrestProduced
+	arrest.Events = append(arrest.Events, *event)
+	return putArrest(ctx, arrest)
+}
+
+// RecordRemand records a magistrate's order remanding a produced arrestee to police or
+// judicial custody until remandUntil. The SHO or an IO of the arrest's station or the
+// judiciary may record it, and an order reference is required.
+func (s *SmartContract) RecordRemand(ctx contractapi.TransactionContextInterface, firID string, arrestNo int, custodyType, remandUntil, orderRef string) error {
+	arrest, event, err := readArrestForEvent(ctx, firID, arrestNo, ArrestRemanded)
+	if err != nil {
+		return err
+	}
+	if arrest.Status != ArrestProduced && arrest.Status != ArrestRemanded {
+		return fmt.Errorf("arrest %d under FIR %s must be produced before a magistrate before remand", arrestNo, firID)
+	}
+	if custodyType != RemandPolice && custodyType != RemandJudicial {
+		return fmt.Errorf("invalid custody type %q: expected %s or %s", custodyType, RemandPolice, RemandJudicial)
+	}
+	if remandUntil == "" || orderRef == "" {
+		return fmt.Errorf("a remand requires the date it runs until and an order reference")
+	}
+
+	event.CustodyType = custodyType
+	event.RemandUntil = remandUntil
+	event.OrderRef = orderRef
+	arrest.Status = ArrestRemanded
+	arrest.Events = append(arrest.Events, *event)
+	return putArrest(ctx, arrest)
+}
+
+// RecordRelease records the release of an arrestee, for example on bail or discharge.
+// The SHO or an IO of the arrest's station or the judiciary may record it, and a reason
+// is required.
+func (s *SmartContract) RecordRelease(ctx contractapi.TransactionContextInterface, firID string, arrestNo int, reason, orderRef string) error {
+	arrest, event, err := readArrestForEvent(ctx, firID, arrestNo, ArrestReleased)
+	if err != nil {
+		return err
+	}
+	if reason == "" {
+		return fmt.Errorf("a reason is required to release arrest %d under FIR %s", arrestNo, firID)
+	}
+
+	event.Remarks = reason
+	event.OrderRef = orderRef
+	arrest.Status = ArrestReleased
+	arrest.Events = append(arrest.Events, *event)
+	return putArrest(ctx, arrest)
+}
+
+// GetArrests returns every arrest under a FIR in order
+func (s *SmartContract) GetArrests(ctx contractapi.TransactionContextInterface, firID string) ([]*Arrest, error) {
+	if _, err := readActiveFIR(ctx, firID); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(arrestKeyPrefix, []string{firID})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var arrests []*Arrest
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var arrest Arrest
+		err = json.Unmarshal(queryResponse.Value, &arrest)
+		if err != nil {
+			return nil, err
+		}
+		arrests = append(arrests, &arrest)
+	}
+	return arrests, nil
+}
+
+// GetOverdueProductions returns the arrestees not produced before a magistrate within 24
+// hours of arrest: those whose deadline passed before the query transaction without a
+// production, whether or not they are still in custody, and those produced after it.
+// Requires CouchDB.
+func (s *SmartContract) GetOverdueProductions(ctx contractapi.TransactionContextInterface) ([]*Arrest, error) {
+	now, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	queryJSON, err := json.Marshal(map[string]interface{}{
+		"selector": map[string]interface{}{
+			"DocType":            arrestDocType,
+			"ProductionDeadline": map[string]interface{}{"$lt": now},
+			"$or": []interface{}{
+				map[string]interface{}{"ProducedAt": map[string]interface{}{"$exists": false}},
+				map[string]interface{}{"ProducedLate": true},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run query: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var arrests []*Arrest
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var arrest Arrest
+		err = json.Unmarshal(queryResponse.Value, &arrest)
+		if err != nil {
+			return nil, err
+		}
+		arrests = append(arrests, &arrest)
+	}
+	return arrests, nil
+}
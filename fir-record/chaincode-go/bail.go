@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+const (
+	bailDocType   = "bail"
+	bailKeyPrefix = "bail"
+)
+
+// BailExecution is the police record of releasing a person on bail
+type BailExecution struct {
+	ReleasedBy  string `json:"ReleasedBy"`
+	ReleasedMSP string `json:"ReleasedMSP"`
+	ReleasedAt  string `json:"ReleasedAt"`
+	Remarks     string `json:"Remarks"`
+	TxID        string `json:"TxID"`
+}
+
+// BailOrder is a bail order granted by the judiciary against a FIR or a person. The
+// judiciary writes the grant and cancellation fields and the police write only Execution.
+type BailOrder struct {
+	DocType string `json:"DocType"`
+	BailID  string `json:"BailID"`
+
+	// FIRID, PartyNo and ArrestNo are set when bail is granted under a FIR; Station is the
+	// FIR's station, or the station directed to release a person named alone
+	FIRID      string `json:"FIRID,omitempty" metadata:",optional"`
+	PartyNo    int    `json:"PartyNo,omitempty" metadata:",optional"`
+	ArrestNo   int    `json:"ArrestNo,omitempty" metadata:",optional"`
+	Station    string `json:"Station"`
+	PersonName string `json:"PersonName"`
+	PersonID   string `json:"PersonID,omitempty" metadata:",optional"`
+
+	Court        string   `json:"Court"`
+	Conditions   []string `json:"Conditions,omitempty" metadata:",optional"`
+	SuretyAmount int64    `json:"SuretyAmount"`
+	IssuedBy     string   `json:"IssuedBy"`
+	IssuedMSP    string   `json:"IssuedMSP"`
+	IssuedAt     string   `json:"IssuedAt"`
+	TxID         string   `json:"TxID"`
+
+	Cancelled    bool   `json:"Cancelled"`
+	CancelledAt  string `json:"CancelledAt,omitempty" metadata:",optional"`
+	CancelReason string `json:"CancelReason,omitempty" metadata:",optional"`
+
+	Execution *BailExecution `json:"Execution,omitempty" metadata:",optional"`
+}
+
+// bailKey returns the world state key for a bail order
+func bailKey(ctx contractapi.TransactionContextInterface, bailID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(bailKeyPrefix, []string{bailID})
+}
+
+func readBailOrder(ctx contractapi.TransactionContextInterface, bailID string) (*BailOrder, error) {
+	key, err := bailKey(ctx, bailID)
+	if err != nil {
+		return nil, err
+	}
+	bailJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if bailJSON == nil {
+		return nil, fmt.Errorf("the bail order %s does not exist", bailID)
+	}
+
+	var bail BailOrder
+	err = json.Unmarshal(bailJSON, &bail)
+	if err != nil {
+		return nil, err
+	}
+	return &bail, nil
+}
+
+func putBailOrder(ctx contractapi.TransactionContextInterface, bail *BailOrder) error {
+	key, err := bailKey(ctx, bail.BailID)
+	if err != nil {
+		return err
+	}
+	bail.DocType = bailDocType
+
+	bailJSON, err := json.Marshal(bail)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, bailJSON)
+}
+
+// GrantBail records a bail order granted by the judiciary. bailJSON carries the BailID,
+// Court, SuretyAmount, optional Conditions and either a FIRID (with an optional accused
+// PartyNo and ArrestNo) or a Station and PersonName, plus an optional PersonID. Only the
+// judiciary may grant bail.
+func (s *SmartContract) GrantBail(ctx contractapi.TransactionContextInterface, bailJSON string) error {
+	if err := onlyJudiciary(ctx); err != nil {
+		return err
+	}
+
+	var input BailOrder
+	err := json.Unmarshal([]byte(bailJSON), &input)
+	if err != nil {
+		return fmt.Errorf("failed to parse bail order: %v", err)
+	}
+	if input.BailID == "" || input.Court == "" {
+		return fmt.Errorf("a bail order requires a bail ID and the granting court")
+	}
+	if input.SuretyAmount < 0 {
+		return fmt.Errorf("invalid surety amount %d", input.SuretyAmount)
+	}
+	if err := resolveCourtSubject(ctx, input.FIRID, input.PartyNo, &input.Station, &input.PersonName); err != nil {
+		return err
+	}
+	if input.ArrestNo != 0 {
+		if input.FIRID == "" {
+			return fmt.Errorf("an arrest number can only be given with a FIR")
+		}
+		arrest, err := readArrest(ctx, input.FIRID, input.ArrestNo)
+		if err != nil {
+			return err
+		}
+		if input.PartyNo != 0 && arrest.PartyNo != input.PartyNo {
+			return fmt.Errorf("arrest %d under FIR %s is of party %d, not party %d", input.ArrestNo, input.FIRID, arrest.PartyNo, input.PartyNo)
+		}
+		input.PartyNo = arrest.PartyNo
+		input.PersonName = arrest.PersonName
+	}
+
+	key, err := bailKey(ctx, input.BailID)
+	if err != nil {
+		return err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("the bail order %s already exists", input.BailID)
+	}
+
+	mspid, submitter, err := getSubmitter(ctx)
+	if err != nil {
+		return err
+	}
+	issuedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	bail := BailOrder{
+		BailID:       input.BailID,
+		FIRID:        input.FIRID,
+		PartyNo:      input.PartyNo,
+		ArrestNo:     input.ArrestNo,
+		Station:      input.Station,
+		PersonName:   input.PersonName,
+		PersonID:     input.PersonID,
+		Court:        input.Court,
+		Conditions:   input.Conditions,
+		SuretyAmount: input.SuretyAmount,
+		IssuedBy:     submitter,
+		IssuedMSP:    mspid,
+		IssuedAt:     issuedAt,
+		TxID:         ctx.GetStub().GetTxID(),
+	}
+	if err := putBailOrder(ctx, &bail); err != nil {
+		return err
+	}
+	if bail.FIRID == "" {
+		return nil
+	}
+	return putFIRLink(ctx, bail.FIRID, linkBail, bail.BailID)
+}
+
+// CancelBail cancels a bail order. Only the judiciary may cancel bail, and a reason is required.
+func (s *SmartContract) CancelBail(ctx contractapi.TransactionContextInterface, bailID, reason string) error {
+	if err := onlyJudiciary(ctx); err != nil {
+		return err
+	}
+	if reason == "" {
+		return fmt.Errorf("a reason is required to cancel bail order %s", bailID)
+	}
+	bail, err := readBailOrder(ctx, bailID)
+	if err != nil {
+		return err
+	}
+	if bail.Cancelled {
+		return fmt.Errorf("the bail order %s is already cancelled", bailID)
+	}
+	cancelledAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	bail.Cancelled = true
+	bail.CancelledAt = cancelledAt
+	bail.CancelReason = reason
+	return putBailOrder(ctx, bail)
+}
+
+// ExecuteBail records that the police released a person on a bail order. The releasing
+// officer and time are taken from the submitting identity and the transaction timestamp,
+// and the officer must be active and posted to the order's station. When the order names
+// an arrest, the release is also recorded on the arrest; see arrest.go.
+func (s *SmartContract) ExecuteBail(ctx contractapi.TransactionContextInterface, bailID, remarks string) error {
+	bail, err := readBailOrder(ctx, bailID)
+	if err != nil {
+		return err
+	}
+	if err := requireStationRole(ctx, bail.Station, policeRoles...); err != nil {
+		return err
+	}
+	officerID, err := getOfficerID(ctx)
+	if err != nil {
+		return err
+	}
+	if err := validateOfficer(ctx, officerID, bail.Station); err != nil {
+		return err
+	}
+	if bail.Cancelled {
+		return fmt.Errorf("the bail order %s has been cancelled", bailID)
+	}
+	if bail.Execution != nil {
+		return fmt.Errorf("the bail order %s has already been executed", bailID)
+	}
+
+	mspid, _, err := getSubmitter(ctx)
+	if err != nil {
+		return err
+	}
+	releasedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	if bail.ArrestNo != 0 {
+		arrest, err := readArrest(ctx, bail.FIRID, bail.ArrestNo)
+		if err != nil {
+			return err
+		}
+		if arrest.Status != ArrestReleased {
+			arrest.Status = ArrestReleased
+			arrest.Events = append(arrest.Events, ArrestEvent{
+				Status:      ArrestReleased,
+				RecordedBy:  officerID,
+				RecordedMSP: mspid,
+				RecordedAt:  releasedAt,
+				TxID:        ctx.GetStub().GetTxID(),
+				OrderRef:    bailID,
+				Remarks:     "released on bail",
+			})
+			if err := putArrest(ctx, arrest); err != nil {
+				return err
+			}
+		}
+	}
+
+	bail.Execution = &BailExecution{
+		ReleasedBy:  officerID,
+		ReleasedMSP: mspid,
+		ReleasedAt:  releasedAt,
+		Remarks:     remarks,
+		TxID:        ctx.GetStub().GetTxID(),
+	}
+	return putBailOrder(ctx, bail)
+}
+
+// ReadBailOrder retrieves a bail order by ID
+func (s *SmartContract) ReadBailOrder(ctx contractapi.TransactionContextInterface, bailID string) (*BailOrder, error) {
+	return readBailOrder(ctx, bailID)
+}
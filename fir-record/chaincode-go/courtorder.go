@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Court order types the judiciary can attach to a FIR
+const (
+	OrderRemand            = "Remand"
+	OrderBail              = "Bail"
+	OrderStay              = "Stay"
+	OrderVacateStay        = "VacateStay"
+	OrderClosureAcceptance = "ClosureAcceptance"
+	OrderReopening         = "Reopening"
+)
+
+// courtTransition is the status change a court order implies
+type courtTransition struct {
+	from, to string
+}
+
+// courtOrderTransitions lists the orders that move a FIR through its lifecycle.
+// Orders not listed here are recorded without changing the FIR's status.
+var courtOrderTransitions = map[string]courtTransition{
+	OrderClosureAcceptance: {from: StatusClosureReportFiled, to: StatusClosed},
+	OrderReopening:         {from: StatusClosed, to: StatusReopened},
+}
+
+// CourtOrder is an order of the judiciary recorded against a FIR
+type CourtOrder struct {
+	OrderType  string `json:"OrderType"`
+	OrderRef   string `json:"OrderRef"`
+	OrderDate  string `json:"OrderDate"`
+	Remarks    string `json:"Remarks"`
+	IssuedBy   string `json:"IssuedBy"`
+	IssuedMSP  string `json:"IssuedMSP"`
+	RecordedAt string `json:"RecordedAt"`
+	TxID       string `json:"TxID"`
+	FromStatus string `json:"FromStatus"`
+	ToStatus   string `json:"ToStatus"`
+}
+
+// AttachCourtOrder records a court order against a FIR and applies the status change
+// or stay the order implies. Only the judiciary may call it, and it touches no
+// police-authored fields.
+func (s *SmartContract) AttachCourtOrder(ctx contractapi.TransactionContextInterface, firID, orderType, orderRef, orderDate, remarks string) error {
+	if err := onlyJudiciary(ctx); err != nil {
+		return err
+	}
+	switch orderType {
+	case OrderRemand, OrderBail, OrderStay, OrderVacateStay, OrderClosureAcceptance, OrderReopening:
+	default:
+		return fmt.Errorf("invalid court order type %q", orderType)
+	}
+	if orderRef == "" {
+		return fmt.Errorf("a court order on FIR %s requires an order reference", firID)
+	}
+
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return err
+	}
+
+	mspid, submitter, err := getSubmitter(ctx)
+	if err != nil {
+		return err
+	}
+	recordedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	order := CourtOrder{
+		OrderType:  orderType,
+		OrderRef:   orderRef,
+		OrderDate:  orderDate,
+		Remarks:    remarks,
+		IssuedBy:   submitter,
+		IssuedMSP:  mspid,
+		RecordedAt: recordedAt,
+		TxID:       ctx.GetStub().GetTxID(),
+		FromStatus: fir.Status,
+		ToStatus:   fir.Status,
+	}
+
+	if orderType == OrderClosureAcceptance && fir.OpenReportSeq != 0 {
+		return fmt.Errorf("the FIR %s has a final report pending; use AcceptFinalReport to accept it", firID)
+	}
+	if transition, ok := courtOrderTransitions[orderType]; ok {
+		current := normalizeStatus(fir.Status)
+		if current != transition.from {
+			return fmt.Errorf("invalid status transition for FIR %s: a %s order requires status %s, but the FIR is %s", firID, orderType, transition.from, current)
+		}
+		fir.Status = transition.to
+		order.ToStatus = transition.to
+	}
+
+	switch orderType {
+	case OrderStay:
+		if fir.Stayed {
+			return fmt.Errorf("the FIR %s is already stayed", firID)
+		}
+		fir.Stayed = true
+	case OrderVacateStay:
+		if !fir.Stayed {
+			return fmt.Errorf("the FIR %s is not stayed", firID)
+		}
+		fir.Stayed = false
+	}
+
+	fir.CourtOrders = append(fir.CourtOrders, order)
+	if err := putFIR(ctx, fir); err != nil {
+		return err
+	}
+	if order.ToStatus == order.FromStatus {
+		return nil
+	}
+	return emitEvent(ctx, EventFIRStatusChanged, FIREvent{FIRID: firID, Station: fir.Station, Status: order.ToStatus, PreviousStatus: order.FromStatus, OrderRef: orderRef})
+}
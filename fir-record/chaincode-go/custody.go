@@ -0,0 +1,347 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Kinds of evidence holder
+const (
+	HolderOfficer     = "Officer"
+	HolderForensicLab = "ForensicLab"
+	HolderMalkhana    = "Malkhana"
+	HolderCourt       = "Court"
+)
+
+// Custody transfer states
+const (
+	TransferPending   = "Pending"
+	TransferAccepted  = "Accepted"
+	TransferCancelled = "Cancelled"
+	TransferRejected  = "Rejected"
+)
+
+// custodyMSPs lists the organisations that may hold evidence: the police and the courts
+var custodyMSPs = map[string]bool{
+	"Org1MSP":    true,
+	judiciaryMSP: true,
+}
+
+const custodyKeyPrefix = "custody"
+
+// CustodyTransfer records one handoff of an evidence item. Holders are identified by
+// officer ID (see getOfficerID) and MSP, and each side of the handoff is recorded with
+// the submitting certificate's fingerprint and the transaction ID. A pending handoff
+// that is cancelled by the holder or rejected by the receiver records who closed it
+// and why in the Closed fields.
+type CustodyTransfer struct {
+	EvidenceID   string `json:"EvidenceID"`
+	Seq          int    `json:"Seq"`
+	Status       string `json:"Status"`
+	Reason       string `json:"Reason"`
+	FromHolder   string `json:"FromHolder"`
+	FromMSP      string `json:"FromMSP"`
+	FromCertHash string `json:"FromCertHash"`
+	InitiatedAt  string `json:"InitiatedAt"`
+	InitiateTxID string `json:"InitiateTxID"`
+	ToHolder     string `json:"ToHolder"`
+	ToMSP        string `json:"ToMSP"`
+	ToHolderType string `json:"ToHolderType"`
+	ToCertHash   string `json:"ToCertHash"`
+	AcceptedAt   string `json:"AcceptedAt"`
+	AcceptTxID   string `json:"AcceptTxID"`
+	ClosedBy     string `json:"ClosedBy,omitempty" metadata:",optional"`
+	ClosedAt     string `json:"ClosedAt,omitempty" metadata:",optional"`
+	CloseReason  string `json:"CloseReason,omitempty" metadata:",optional"`
+	CloseTxID    string `json:"CloseTxID,omitempty" metadata:",optional"`
+}
+
+// custodyKey returns the world state key for a custody transfer. Sequence numbers are
+// zero padded so that partial key scans return the chain in order.
+func custodyKey(ctx contractapi.TransactionContextInterface, evidenceID string, seq int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(custodyKeyPrefix, []string{evidenceID, fmt.Sprintf("%06d", seq)})
+}
+
+// getHolder returns the caller's officer ID and MSP ID, as recorded for evidence holders
+func getHolder(ctx contractapi.TransactionContextInterface) (string, string, error) {
+	mspid, err := getMSPID(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to get MSP ID: %v", err)
+	}
+	holder, err := getOfficerID(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	return holder, mspid, nil
+}
+
+// getCertHash returns the hex SHA-256 fingerprint of the submitting client's certificate
+func getCertHash(ctx contractapi.TransactionContextInterface) (string, error) {
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return "", fmt.Errorf("unable to get client certificate: %v", err)
+	}
+	hash := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func readCustodyTransfer(ctx contractapi.TransactionContextInterface, evidenceID string, seq int) (*CustodyTransfer, error) {
+	key, err := custodyKey(ctx, evidenceID, seq)
+	if err != nil {
+		return nil, err
+	}
+	transferJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if transferJSON == nil {
+		return nil, fmt.Errorf("custody transfer %d of evidence %s does not exist", seq, evidenceID)
+	}
+
+	var transfer CustodyTransfer
+	err = json.Unmarshal(transferJSON, &transfer)
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func putCustodyTransfer(ctx contractapi.TransactionContextInterface, transfer *CustodyTransfer) error {
+	key, err := custodyKey(ctx, transfer.EvidenceID, transfer.Seq)
+	if err != nil {
+		return err
+	}
+	transferJSON, err := json.Marshal(transfer)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, transferJSON)
+}
+
+// InitiateTransfer starts a handoff of an evidence item from its current holder,
+// who must be the submitter, to the named receiver. toMSP must be an organisation that
+// holds evidence, a court must be in the judiciary, and an officer receiver must be an
+// active officer in policeman-record. Evidence of a sealed FIR cannot change hands.
+func (c *EvidenceContract) InitiateTransfer(ctx contractapi.TransactionContextInterface, evidenceID, toHolder, toMSP, toHolderType, reason string) error {
+	switch toHolderType {
+	case HolderOfficer, HolderForensicLab, HolderMalkhana, HolderCourt:
+	default:
+		return fmt.Errorf("invalid holder type %q", toHolderType)
+	}
+	if toHolder == "" {
+		return fmt.Errorf("the receiving holder is required")
+	}
+	if !custodyMSPs[toMSP] {
+		return fmt.Errorf("unknown receiving organisation %q", toMSP)
+	}
+	if (toHolderType == HolderCourt) != (toMSP == judiciaryMSP) {
+		return fmt.Errorf("holder type %s cannot be held by %s", toHolderType, toMSP)
+	}
+
+	evidence, err := readEvidence(ctx, evidenceID)
+	if err != nil {
+		return err
+	}
+	if _, err := readActiveFIR(ctx, evidence.FIRID); err != nil {
+		return err
+	}
+	if evidence.OpenTransferSeq != 0 {
+		return fmt.Errorf("evidence %s already has an open transfer (%d); it must be accepted, rejected or cancelled first", evidenceID, evidence.OpenTransferSeq)
+	}
+
+	holder, mspid, err := getHolder(ctx)
+	if err != nil {
+		return err
+	}
+	if holder != evidence.CurrentHolder || mspid != evidence.CurrentHolderMSP {
+		return fmt.Errorf("access denied: evidence %s is held by %s (%s), not %s (%s)", evidenceID, evidence.CurrentHolder, evidence.CurrentHolderMSP, holder, mspid)
+	}
+	if toHolder == holder && toMSP == mspid {
+		return fmt.Errorf("evidence %s is already held by %s (%s)", evidenceID, toHolder, toMSP)
+	}
+	if toHolderType == HolderOfficer {
+		officer, err := readOfficer(ctx, toHolder)
+		if err != nil {
+			return err
+		}
+		if officer.EmploymentStatus != employmentStatusActive {
+			return fmt.Errorf("the officer %s is not active (employment status %q)", toHolder, officer.EmploymentStatus)
+		}
+	}
+
+	certHash, err := getCertHash(ctx)
+	if err != nil {
+		return err
+	}
+	initiatedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	transfer := CustodyTransfer{
+		EvidenceID:   evidenceID,
+		Seq:          evidence.TransferCount + 1,
+		Status:       TransferPending,
+		Reason:       reason,
+		FromHolder:   holder,
+		FromMSP:      mspid,
+		FromCertHash: certHash,
+		InitiatedAt:  initiatedAt,
+		InitiateTxID: ctx.GetStub().GetTxID(),
+		ToHolder:     toHolder,
+		ToMSP:        toMSP,
+		ToHolderType: toHolderType,
+	}
+	if err := putCustodyTransfer(ctx, &transfer); err != nil {
+		return err
+	}
+
+	evidence.TransferCount = transfer.Seq
+	evidence.OpenTransferSeq = transfer.Seq
+	return putEvidence(ctx, evidence)
+}
+
+// AcceptTransfer completes the open handoff of an evidence item. Only the receiver
+// named in InitiateTransfer may accept it, and not once the FIR has been sealed; the
+// handoff can still be cancelled or rejected.
+func (c *EvidenceContract) AcceptTransfer(ctx contractapi.TransactionContextInterface, evidenceID string) error {
+	evidence, err := readEvidence(ctx, evidenceID)
+	if err != nil {
+		return err
+	}
+	if _, err := readActiveFIR(ctx, evidence.FIRID); err != nil {
+		return err
+	}
+	if evidence.OpenTransferSeq == 0 {
+		return fmt.Errorf("evidence %s has no open transfer", evidenceID)
+	}
+
+	transfer, err := readCustodyTransfer(ctx, evidenceID, evidence.OpenTransferSeq)
+	if err != nil {
+		return err
+	}
+
+	holder, mspid, err := getHolder(ctx)
+	if err != nil {
+		return err
+	}
+	if holder != transfer.ToHolder || mspid != transfer.ToMSP {
+		return fmt.Errorf("access denied: transfer %d of evidence %s is addressed to %s (%s), not %s (%s)", transfer.Seq, evidenceID, transfer.ToHolder, transfer.ToMSP, holder, mspid)
+	}
+
+	certHash, err := getCertHash(ctx)
+	if err != nil {
+		return err
+	}
+	acceptedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	transfer.Status = TransferAccepted
+	transfer.ToCertHash = certHash
+	transfer.AcceptedAt = acceptedAt
+	transfer.AcceptTxID = ctx.GetStub().GetTxID()
+	if err := putCustodyTransfer(ctx, transfer); err != nil {
+		return err
+	}
+
+	evidence.CurrentHolder = transfer.ToHolder
+	evidence.CurrentHolderMSP = transfer.ToMSP
+	evidence.CurrentHolderType = transfer.ToHolderType
+	evidence.OpenTransferSeq = 0
+	return putEvidence(ctx, evidence)
+}
+
+// CancelTransfer withdraws the open handoff of an evidence item, for example when the
+// receiver was named wrongly. Only the current holder who initiated it may cancel it.
+func (c *EvidenceContract) CancelTransfer(ctx contractapi.TransactionContextInterface, evidenceID, reason string) error {
+	return closeOpenTransfer(ctx, evidenceID, TransferCancelled, reason)
+}
+
+// RejectTransfer refuses the open handoff of an evidence item, for example when the
+// seals do not match on receipt. Only the receiver named in InitiateTransfer may reject it.
+func (c *EvidenceContract) RejectTransfer(ctx contractapi.TransactionContextInterface, evidenceID, reason string) error {
+	return closeOpenTransfer(ctx, evidenceID, TransferRejected, reason)
+}
+
+// closeOpenTransfer ends the open handoff of an evidence item without moving custody. A
+// cancellation must come from the sending holder and a rejection from the named receiver.
+func closeOpenTransfer(ctx contractapi.TransactionContextInterface, evidenceID, status, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("a reason is required")
+	}
+	evidence, err := readEvidence(ctx, evidenceID)
+	if err != nil {
+		return err
+	}
+	if evidence.OpenTransferSeq == 0 {
+		return fmt.Errorf("evidence %s has no open transfer", evidenceID)
+	}
+	transfer, err := readCustodyTransfer(ctx, evidenceID, evidence.OpenTransferSeq)
+	if err != nil {
+		return err
+	}
+
+	holder, mspid, err := getHolder(ctx)
+	if err != nil {
+		return err
+	}
+	party, partyMSP := transfer.FromHolder, transfer.FromMSP
+	if status == TransferRejected {
+		party, partyMSP = transfer.ToHolder, transfer.ToMSP
+	}
+	if holder != party || mspid != partyMSP {
+		return fmt.Errorf("access denied: only %s (%s) may mark transfer %d of evidence %s %s", party, partyMSP, transfer.Seq, evidenceID, status)
+	}
+
+	closedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	transfer.Status = status
+	transfer.ClosedBy = holder
+	transfer.ClosedAt = closedAt
+	transfer.CloseReason = reason
+	transfer.CloseTxID = ctx.GetStub().GetTxID()
+	if err := putCustodyTransfer(ctx, transfer); err != nil {
+		return err
+	}
+
+	evidence.OpenTransferSeq = 0
+	return putEvidence(ctx, evidence)
+}
+
+// GetCustodyChain returns every handoff of an evidence item in order, to those who may
+// read the item; see ReadEvidence
+func (c *EvidenceContract) GetCustodyChain(ctx contractapi.TransactionContextInterface, evidenceID string) ([]*CustodyTransfer, error) {
+	if _, err := c.ReadEvidence(ctx, evidenceID); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(custodyKeyPrefix, []string{evidenceID})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var chain []*CustodyTransfer
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var transfer CustodyTransfer
+		err = json.Unmarshal(queryResponse.Value, &transfer)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, &transfer)
+	}
+	return chain, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+const diaryKeyPrefix = "diary"
+
+// CaseDiaryEntry is one entry in the case diary of a FIR. Entries are never changed once
+// written; a mistake is corrected by a later entry whose CorrectsSeq names the earlier one.
+type CaseDiaryEntry struct {
+	FIRID        string   `json:"FIRID"`
+	Seq          int      `json:"Seq"`
+	EntryDate    string   `json:"EntryDate"`
+	Author       string   `json:"Author"`
+	AuthorMSP    string   `json:"AuthorMSP"`
+	Text         string   `json:"Text"`
+	EvidenceRefs []string `json:"EvidenceRefs,omitempty" metadata:",optional"`
+	CorrectsSeq  int      `json:"CorrectsSeq,omitempty" metadata:",optional"`
+	TxID         string   `json:"TxID"`
+}
+
+// CaseDiaryPage holds one page of case diary entries and the bookmark for the next page
+type CaseDiaryPage struct {
+	Entries             []*CaseDiaryEntry `json:"Entries,omitempty" metadata:",optional"`
+	FetchedRecordsCount int32             `json:"FetchedRecordsCount"`
+	Bookmark            string            `json:"Bookmark"`
+}
+
+// diaryKey returns the world state key for a case diary entry. Sequence numbers are
+// zero padded so that partial key scans return the diary in order.
+func diaryKey(ctx contractapi.TransactionContextInterface, firID string, seq int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(diaryKeyPrefix, []string{firID, fmt.Sprintf("%06d", seq)})
+}
+
+// AddCaseDiaryEntry appends an entry to the case diary of a FIR and returns its sequence number.
+// evidenceRefsJSON is an optional JSON array of evidence IDs registered under the FIR, and
+// correctsSeq is the sequence number of the entry being corrected, or 0. Only the SHO or an
+// IO of the FIR's station may write, and once an IO is assigned only that IO or the SHO.
+func (s *SmartContract) AddCaseDiaryEntry(ctx contractapi.TransactionContextInterface, firID, text, evidenceRefsJSON string, correctsSeq int) (int, error) {
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return 0, err
+	}
+	author, err := requireInvestigator(ctx, fir, RoleSHO, RoleIO)
+	if err != nil {
+		return 0, err
+	}
+
+	if text == "" {
+		return 0, fmt.Errorf("a case diary entry requires text")
+	}
+	if correctsSeq < 0 || correctsSeq > fir.DiaryEntryCount {
+		return 0, fmt.Errorf("the FIR %s has no case diary entry %d to correct", firID, correctsSeq)
+	}
+
+	var evidenceRefs []string
+	if evidenceRefsJSON != "" {
+		err = json.Unmarshal([]byte(evidenceRefsJSON), &evidenceRefs)
+		if err != nil {
+			return 0, fmt.Errorf("evidence references must be a JSON array of evidence IDs: %v", err)
+		}
+	}
+	for _, evidenceID := range evidenceRefs {
+		evidence, err := readEvidence(ctx, evidenceID)
+		if err != nil {
+			return 0, err
+		}
+		if evidence.FIRID != firID {
+			return 0, fmt.Errorf("the evidence %s belongs to FIR %s, not %s", evidenceID, evidence.FIRID, firID)
+		}
+	}
+
+	mspid, _, err := getSubmitter(ctx)
+	if err != nil {
+		return 0, err
+	}
+	entryDate, err := getTxTimestamp(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	fir.DiaryEntryCount++
+	entry := CaseDiaryEntry{
+		FIRID:        firID,
+		Seq:          fir.DiaryEntryCount,
+		EntryDate:    entryDate,
+		Author:       author,
+		AuthorMSP:    mspid,
+		Text:         text,
+		EvidenceRefs: evidenceRefs,
+		CorrectsSeq:  correctsSeq,
+		TxID:         ctx.GetStub().GetTxID(),
+	}
+	key, err := diaryKey(ctx, firID, entry.Seq)
+	if err != nil {
+		return 0, err
+	}
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return 0, err
+	}
+	err = ctx.GetStub().PutState(key, entryJSON)
+	if err != nil {
+		return 0, err
+	}
+	if err := putFIR(ctx, fir); err != nil {
+		return 0, err
+	}
+	return entry.Seq, nil
+}
+
+// GetCaseDiary returns up to pageSize case diary entries of a FIR in order, starting at bookmark.
+// Pass an empty bookmark for the first page. The SHO or an IO of the FIR's station and the
+// judiciary may read the diary.
+func (s *SmartContract) GetCaseDiary(ctx contractapi.TransactionContextInterface, firID string, pageSize int32, bookmark string) (*CaseDiaryPage, error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return nil, err
+	}
+	if err := onlyJudiciary(ctx); err != nil {
+		if err := requireStationRole(ctx, fir.Station, RoleSHO, RoleIO); err != nil {
+			return nil, err
+		}
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(diaryKeyPrefix, []string{firID}, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var entries []*CaseDiaryEntry
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var entry CaseDiaryEntry
+		err = json.Unmarshal(queryResponse.Value, &entry)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+
+	return &CaseDiaryPage{
+		Entries:             entries,
+		FetchedRecordsCount: metadata.FetchedRecordsCount,
+		Bookmark:            metadata.Bookmark,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// FIR chaincode event names. Filing and status changes follow a FIR through its
+// lifecycle; FIRSealed and FIRTransferred report it leaving its station's hands.
+const (
+	EventFIRFiled         = "FIRFiled"
+	EventFIRStatusChanged = "FIRStatusChanged"
+	EventFIRSealed        = "FIRSealed"
+	EventFIRTransferred   = "FIRTransferred"
+)
+
+// FIREvent is the payload of FIR chaincode events
+type FIREvent struct {
+	FIRID          string `json:"FIRID"`
+	Station        string `json:"Station"`
+	Status         string `json:"Status"`
+	PreviousStatus string `json:"PreviousStatus,omitempty"`
+	Disposition    string `json:"Disposition,omitempty"`
+	OrderRef       string `json:"OrderRef,omitempty"`
+
+	TransferredFrom string `json:"TransferredFrom,omitempty"`
+}
+
+// emitEvent sets a chaincode event with a JSON payload
+func emitEvent(ctx contractapi.TransactionContextInterface, name string, payload interface{}) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().SetEvent(name, payloadJSON)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// EvidenceContract records evidence collected against a FIR. Its transactions are
+// namespaced under "evidence:" in the FIR chaincode.
+type EvidenceContract struct {
+	contractapi.Contract
+}
+
+const (
+	evidenceDocType   = "evidence"
+	evidenceKeyPrefix = "evidence"
+)
+
+// Evidence anchors an off-chain evidence file to a FIR by its SHA-256 digest
+type Evidence struct {
+	DocType       string `json:"DocType"`
+	EvidenceID    string `json:"EvidenceID"`
+	FIRID         string `json:"FIRID"`
+	SHA256        string `json:"SHA256"`
+	Size          int64  `json:"Size"`
+	MediaType     string `json:"MediaType"`
+	Description   string `json:"Description"`
+	CollectedBy   string `json:"CollectedBy"`
+	CollectedAt   string `json:"CollectedAt"`
+	RegisteredBy  string `json:"RegisteredBy"`
+	RegisteredMSP string `json:"RegisteredMSP"`
+
+	// Custody state; see custody.go
+	CurrentHolder     string `json:"CurrentHolder"`
+	CurrentHolderMSP  string `json:"CurrentHolderMSP"`
+	CurrentHolderType string `json:"CurrentHolderType"`
+	TransferCount     int    `json:"TransferCount"`
+	OpenTransferSeq   int    `json:"OpenTransferSeq"`
+}
+
+// evidenceKey returns the world state key for an evidence item
+func evidenceKey(ctx contractapi.TransactionContextInterface, evidenceID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(evidenceKeyPrefix, []string{evidenceID})
+}
+
+// putEvidence writes an evidence item to world state
+func putEvidence(ctx contractapi.TransactionContextInterface, evidence *Evidence) error {
+	key, err := evidenceKey(ctx, evidence.EvidenceID)
+	if err != nil {
+		return err
+	}
+	evidence.DocType = evidenceDocType
+
+	evidenceJSON, err := json.Marshal(evidence)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, evidenceJSON)
+}
+
+// RegisterEvidence records the digest of an evidence file collected under a FIR.
+// collectedBy is the officer ID of the collecting officer, who must be active and posted
+// to the FIR's station in policeman-record. collectedAt is the RFC 3339 time the evidence
+// was collected and may not be later than the registering transaction.
+func (c *EvidenceContract) RegisterEvidence(ctx contractapi.TransactionContextInterface, evidenceID, firID, sha256Hex string, size int64, mediaType, description, collectedBy, collectedAt string) error {
+	digest := strings.ToLower(sha256Hex)
+	if decoded, err := hex.DecodeString(digest); err != nil || len(decoded) != 32 {
+		return fmt.Errorf("invalid SHA-256 digest %q: expected 64 hex characters", sha256Hex)
+	}
+	if size < 0 {
+		return fmt.Errorf("invalid evidence size %d", size)
+	}
+	if collectedBy == "" {
+		return fmt.Errorf("the collecting officer is required")
+	}
+	collectedTime, err := time.Parse(time.RFC3339, collectedAt)
+	if err != nil {
+		return fmt.Errorf("invalid collection time %q: expected RFC 3339", collectedAt)
+	}
+
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return err
+	}
+	if err := requireStationRole(ctx, fir.Station, policeRoles...); err != nil {
+		return err
+	}
+	if err := validateOfficer(ctx, collectedBy, fir.Station); err != nil {
+		return err
+	}
+
+	exists, err := c.EvidenceExists(ctx, evidenceID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("the evidence %s already exists", evidenceID)
+	}
+
+	holder, mspid, err := getHolder(ctx)
+	if err != nil {
+		return err
+	}
+	registeredAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	if txTime, _ := time.Parse(time.RFC3339, registeredAt); collectedTime.After(txTime) {
+		return fmt.Errorf("the collection time %s is later than the registration time %s", collectedAt, registeredAt)
+	}
+
+	evidence := Evidence{
+		EvidenceID:    evidenceID,
+		FIRID:         firID,
+		SHA256:        digest,
+		Size:          size,
+		MediaType:     mediaType,
+		Description:   description,
+		CollectedBy:   collectedBy,
+		CollectedAt:   collectedTime.UTC().Format(time.RFC3339),
+		RegisteredBy:  holder,
+		RegisteredMSP: mspid,
+
+		CurrentHolder:     holder,
+		CurrentHolderMSP:  mspid,
+		CurrentHolderType: HolderOfficer,
+	}
+	if err := putEvidence(ctx, &evidence); err != nil {
+		return err
+	}
+	return putFIRLink(ctx, firID, linkEvidence, evidenceID)
+}
+
+// ReadEvidence retrieves an evidence item by ID. It may be read by its current holder,
+// the judiciary, or police of the station holding the FIR, and not once the FIR is sealed.
+func (c *EvidenceContract) ReadEvidence(ctx contractapi.TransactionContextInterface, evidenceID string) (*Evidence, error) {
+	evidence, err := readEvidence(ctx, evidenceID)
+	if err != nil {
+		return nil, err
+	}
+	fir, err := readActiveFIR(ctx, evidence.FIRID)
+	if err != nil {
+		return nil, err
+	}
+	holder, mspid, err := getHolder(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if holder == evidence.CurrentHolder && mspid == evidence.CurrentHolderMSP {
+		return evidence, nil
+	}
+	if err := requireEvidenceReader(ctx, fir); err != nil {
+		return nil, err
+	}
+	return evidence, nil
+}
+
+// readEvidence reads an evidence item without checking the caller, for functions that
+// check it themselves
+func readEvidence(ctx contractapi.TransactionContextInterface, evidenceID string) (*Evidence, error) {
+	key, err := evidenceKey(ctx, evidenceID)
+	if err != nil {
+		return nil, err
+	}
+	evidenceJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if evidenceJSON == nil {
+		return nil, fmt.Errorf("the evidence %s does not exist", evidenceID)
+	}
+
+	var evidence Evidence
+	err = json.Unmarshal(evidenceJSON, &evidence)
+	if err != nil {
+		return nil, err
+	}
+	return &evidence, nil
+}
+
+// requireEvidenceReader checks that the caller is in the judiciary or police of the FIR's station
+func requireEvidenceReader(ctx contractapi.TransactionContextInterface, fir *FIR) error {
+	if onlyJudiciary(ctx) == nil {
+		return nil
+	}
+	return requireStationRole(ctx, fir.Station, policeRoles...)
+}
+
+// EvidenceExists checks if an evidence item exists in world state
+func (c *EvidenceContract) EvidenceExists(ctx contractapi.TransactionContextInterface, evidenceID string) (bool, error) {
+	key, err := evidenceKey(ctx, evidenceID)
+	if err != nil {
+		return false, err
+	}
+	evidenceJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	return evidenceJSON != nil, nil
+}
+
+// GetEvidenceForFIR returns all evidence registered against a FIR to the judiciary or
+// police of the FIR's station, unless the FIR is sealed.
+func (c *EvidenceContract) GetEvidenceForFIR(ctx contractapi.TransactionContextInterface, firID string) ([]*Evidence, error) {
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireEvidenceReader(ctx, fir); err != nil {
+		return nil, err
+	}
+	return evidenceForFIR(ctx, firID)
+}
+
+// evidenceForFIR returns all evidence registered against a FIR without checking the
+// caller. It walks the FIR link index, so PurgeFIR and AcceptTransfer can use it safely.
+func evidenceForFIR(ctx contractapi.TransactionContextInterface, firID string) ([]*Evidence, error) {
+	evidenceIDs, err := firLinks(ctx, firID, linkEvidence)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*Evidence
+	for _, evidenceID := range evidenceIDs {
+		evidence, err := readEvidence(ctx, evidenceID)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, evidence)
+	}
+	return items, nil
+}
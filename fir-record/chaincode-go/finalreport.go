@@ -0,0 +1,396 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Final report types
+const (
+	ReportChargesheet = "Chargesheet"
+	ReportClosure     = "ClosureReport"
+)
+
+// Final report states
+const (
+	ReportSubmitted    = "Submitted"
+	ReportAcknowledged = "Acknowledged"
+	ReportAccepted     = "Accepted"
+	ReportReturned     = "Returned"
+)
+
+const finalReportKeyPrefix = "finalreport"
+
+// reportStatuses is the FIR status each type of final report moves the FIR to on submission
+var reportStatuses = map[string]string{
+	ReportChargesheet: StatusChargesheetFiled,
+	ReportClosure:     StatusClosureReportFiled,
+}
+
+// FinalReport is the report the police submit to court at the end of an investigation:
+// a chargesheet or a closure report. The FIR's status follows the report, and a report
+// returned by the court sends the FIR back for further investigation.
+type FinalReport struct {
+	FIRID      string `json:"FIRID"`
+	Seq        int    `json:"Seq"`
+	ReportType string `json:"ReportType"`
+	Status     string `json:"Status"`
+
+	// Chargesheet contents. Accused and witnesses are referred to by party number so
+	// that witness details stay in the FIR's private details; see parties.go
+	Sections        []string `json:"Sections,omitempty" metadata:",optional"`
+	AccusedPartyNos []int    `json:"AccusedPartyNos,omitempty" metadata:",optional"`
+	WitnessPartyNos []int    `json:"WitnessPartyNos,omitempty" metadata:",optional"`
+	EvidenceRefs    []string `json:"EvidenceRefs,omitempty" metadata:",optional"`
+
+	// Reasons is the closure report's grounds for closing the case
+	Reasons string `json:"Reasons,omitempty" metadata:",optional"`
+
+	SubmittedBy string `json:"SubmittedBy"`
+	SubmittedAt string `json:"SubmittedAt"`
+	SubmitTxID  string `json:"SubmitTxID"`
+
+	AcknowledgedBy string `json:"AcknowledgedBy,omitempty" metadata:",optional"`
+	AcknowledgedAt string `json:"AcknowledgedAt,omitempty" metadata:",optional"`
+
+	DecidedBy    string `json:"DecidedBy,omitempty" metadata:",optional"`
+	DecidedAt    string `json:"DecidedAt,omitempty" metadata:",optional"`
+	DecisionTxID string `json:"DecisionTxID,omitempty" metadata:",optional"`
+	OrderRef     string `json:"OrderRef,omitempty" metadata:",optional"`
+	Remarks      string `json:"Remarks,omitempty" metadata:",optional"`
+}
+
+// finalReportKey returns the world state key for a final report of a FIR
+func finalReportKey(ctx contractapi.TransactionContextInterface, firID string, seq int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(finalReportKeyPrefix, []string{firID, fmt.Sprintf("%06d", seq)})
+}
+
+func readFinalReport(ctx contractapi.TransactionContextInterface, firID string, seq int) (*FinalReport, error) {
+	key, err := finalReportKey(ctx, firID, seq)
+	if err != nil {
+		return nil, err
+	}
+	reportJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if reportJSON == nil {
+		return nil, fmt.Errorf("final report %d of FIR %s does not exist", seq, firID)
+	}
+
+	var report FinalReport
+	err = json.Unmarshal(reportJSON, &report)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func putFinalReport(ctx contractapi.TransactionContextInterface, report *FinalReport) error {
+	key, err := finalReportKey(ctx, report.FIRID, report.Seq)
+	if err != nil {
+		return err
+	}
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, reportJSON)
+}
+
+// SubmitChargesheet files a chargesheet against a FIR under investigation and returns its
+// report number. sectionsJSON is a JSON array of the sections of law invoked,
+// accusedJSON and witnessesJSON are JSON arrays of party numbers, and evidenceRefsJSON is an
+// optional JSON array of evidence IDs registered under the FIR. The FIR moves to
+// ChargesheetFiled. Only the SHO or an IO of the FIR's station may submit, and once an IO is
+// assigned only that IO or the SHO.
+func (s *SmartContract) SubmitChargesheet(ctx contractapi.TransactionContextInterface, firID, sectionsJSON, accusedJSON, witnessesJSON, evidenceRefsJSON string) (int, error) {
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return 0, err
+	}
+
+	report := FinalReport{ReportType: ReportChargesheet}
+	err = json.Unmarshal([]byte(sectionsJSON), &report.Sections)
+	if err != nil {
+		return 0, fmt.Errorf("sections must be a JSON array of strings: %v", err)
+	}
+	if len(report.Sections) == 0 {
+		return 0, fmt.Errorf("a chargesheet on FIR %s must invoke at least one section", firID)
+	}
+	err = json.Unmarshal([]byte(accusedJSON), &report.AccusedPartyNos)
+	if err != nil {
+		return 0, fmt.Errorf("accused must be a JSON array of party numbers: %v", err)
+	}
+	if len(report.AccusedPartyNos) == 0 {
+		return 0, fmt.Errorf("a chargesheet on FIR %s must charge at least one accused", firID)
+	}
+	if witnessesJSON != "" {
+		err = json.Unmarshal([]byte(witnessesJSON), &report.WitnessPartyNos)
+		if err != nil {
+			return 0, fmt.Errorf("witnesses must be a JSON array of party numbers: %v", err)
+		}
+	}
+	if evidenceRefsJSON != "" {
+		err = json.Unmarshal([]byte(evidenceRefsJSON), &report.EvidenceRefs)
+		if err != nil {
+			return 0, fmt.Errorf("evidence references must be a JSON array of evidence IDs: %v", err)
+		}
+	}
+
+	accused := make(map[int]bool)
+	for _, party := range fir.Parties {
+		if party.Role == PartyAccused {
+			accused[party.PartyNo] = true
+		}
+	}
+	for _, partyNo := range report.AccusedPartyNos {
+		if !accused[partyNo] {
+			return 0, fmt.Errorf("the FIR %s has no accused with party number %d", firID, partyNo)
+		}
+	}
+	// witnesses are private parties, so only their numbering can be checked here
+	for _, partyNo := range report.WitnessPartyNos {
+		if partyNo <= 0 || partyNo > fir.PartyCount || accused[partyNo] {
+			return 0, fmt.Errorf("the FIR %s has no witness with party number %d", firID, partyNo)
+		}
+	}
+	for _, evidenceID := range report.EvidenceRefs {
+		evidence, err := readEvidence(ctx, evidenceID)
+		if err != nil {
+			return 0, err
+		}
+		if evidence.FIRID != firID {
+			return 0, fmt.Errorf("the evidence %s belongs to FIR %s, not %s", evidenceID, evidence.FIRID, firID)
+		}
+	}
+
+	return submitFinalReport(ctx, fir, &report)
+}
+
+// SubmitClosureReport files a closure report on a FIR under investigation, giving the
+// reasons the case should be closed, and returns its report number. The FIR moves to
+// ClosureReportFiled and is closed only once the court accepts the report. Only the SHO
+// or an IO of the FIR's station may submit, and once an IO is assigned only that IO or the SHO.
+func (s *SmartContract) SubmitClosureReport(ctx contractapi.TransactionContextInterface, firID, reasons string) (int, error) {
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return 0, err
+	}
+	if reasons == "" {
+		return 0, fmt.Errorf("a closure report on FIR %s requires reasons", firID)
+	}
+	return submitFinalReport(ctx, fir, &FinalReport{ReportType: ReportClosure, Reasons: reasons})
+}
+
+// submitFinalReport records a final report on a FIR and moves the FIR to the status the
+// report implies
+func submitFinalReport(ctx contractapi.TransactionContextInterface, fir *FIR, report *FinalReport) (int, error) {
+	officerID, err := requireInvestigator(ctx, fir, RoleSHO, RoleIO)
+	if err != nil {
+		return 0, err
+	}
+	if fir.Stayed {
+		return 0, fmt.Errorf("the FIR %s is stayed by court order and no report can be submitted", fir.FIRID)
+	}
+	if fir.OpenReportSeq != 0 {
+		return 0, fmt.Errorf("the FIR %s already has a final report pending before the court", fir.FIRID)
+	}
+	current := normalizeStatus(fir.Status)
+	if current != StatusUnderInvestigation {
+		return 0, fmt.Errorf("a final report requires FIR %s to be %s, but it is %s", fir.FIRID, StatusUnderInvestigation, current)
+	}
+
+	submittedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	fir.ReportCount++
+	report.FIRID = fir.FIRID
+	report.Seq = fir.ReportCount
+	report.Status = ReportSubmitted
+	report.SubmittedBy = officerID
+	report.SubmittedAt = submittedAt
+	report.SubmitTxID = ctx.GetStub().GetTxID()
+	if err := putFinalReport(ctx, report); err != nil {
+		return 0, err
+	}
+
+	previousStatus := fir.Status
+	fir.Status = reportStatuses[report.ReportType]
+	fir.OpenReportSeq = report.Seq
+	if err := putFIR(ctx, fir); err != nil {
+		return 0, err
+	}
+	if err := emitEvent(ctx, EventFIRStatusChanged, FIREvent{FIRID: fir.FIRID, Station: fir.Station, Status: fir.Status, PreviousStatus: previousStatus}); err != nil {
+		return 0, err
+	}
+	return report.Seq, nil
+}
+
+// readOpenFinalReport returns a FIR and the final report pending before the court,
+// checking that the caller is the judiciary
+func readOpenFinalReport(ctx contractapi.TransactionContextInterface, firID string) (*FIR, *FinalReport, string, error) {
+	if err := onlyJudiciary(ctx); err != nil {
+		return nil, nil, "", err
+	}
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if fir.OpenReportSeq == 0 {
+		return nil, nil, "", fmt.Errorf("the FIR %s has no final report pending before the court", firID)
+	}
+	report, err := readFinalReport(ctx, firID, fir.OpenReportSeq)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	_, submitter, err := getSubmitter(ctx)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return fir, report, submitter, nil
+}
+
+// AcknowledgeFinalReport records that the court has received the pending final report of a
+// FIR. Only the judiciary may call it, and the FIR's status does not change.
+func (s *SmartContract) AcknowledgeFinalReport(ctx contractapi.TransactionContextInterface, firID string) error {
+	_, report, submitter, err := readOpenFinalReport(ctx, firID)
+	if err != nil {
+		return err
+	}
+	if report.Status != ReportSubmitted {
+		return fmt.Errorf("final report %d of FIR %s is already %s", report.Seq, firID, report.Status)
+	}
+	acknowledgedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	report.Status = ReportAcknowledged
+	report.AcknowledgedBy = submitter
+	report.AcknowledgedAt = acknowledgedAt
+	return putFinalReport(ctx, report)
+}
+
+// AcceptFinalReport records the court's acceptance of the pending final report of a FIR.
+// Accepting a chargesheet leaves the FIR ChargesheetFiled for trial; accepting a closure
+// report closes the FIR. Only the judiciary may call it, and an order reference is required.
+func (s *SmartContract) AcceptFinalReport(ctx contractapi.TransactionContextInterface, firID, orderRef, remarks string) error {
+	if orderRef == "" {
+		return fmt.Errorf("accepting the final report of FIR %s requires an order reference", firID)
+	}
+	fir, report, submitter, err := readOpenFinalReport(ctx, firID)
+	if err != nil {
+		return err
+	}
+	decidedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	report.Status = ReportAccepted
+	report.DecidedBy = submitter
+	report.DecidedAt = decidedAt
+	report.DecisionTxID = ctx.GetStub().GetTxID()
+	report.OrderRef = orderRef
+	report.Remarks = remarks
+	if err := putFinalReport(ctx, report); err != nil {
+		return err
+	}
+
+	previousStatus := fir.Status
+	fir.OpenReportSeq = 0
+	if report.ReportType == ReportClosure {
+		fir.Status = StatusClosed
+	}
+	if err := putFIR(ctx, fir); err != nil {
+		return err
+	}
+	if fir.Status == previousStatus {
+		return nil
+	}
+	return emitEvent(ctx, EventFIRStatusChanged, FIREvent{FIRID: firID, Station: fir.Station, Status: fir.Status, PreviousStatus: previousStatus, OrderRef: orderRef})
+}
+
+// requireAcceptedChargesheet checks that the latest final report of a FIR is a
+// chargesheet the court has accepted
+func requireAcceptedChargesheet(ctx contractapi.TransactionContextInterface, fir *FIR) error {
+	if fir.ReportCount == 0 {
+		return fmt.Errorf("the FIR %s has no chargesheet accepted by the court", fir.FIRID)
+	}
+	report, err := readFinalReport(ctx, fir.FIRID, fir.ReportCount)
+	if err != nil {
+		return err
+	}
+	if report.ReportType != ReportChargesheet || report.Status != ReportAccepted {
+		return fmt.Errorf("the FIR %s has no chargesheet accepted by the court", fir.FIRID)
+	}
+	return nil
+}
+
+// ReturnFinalReport returns the pending final report of a FIR to the police, for example
+// for further investigation, and moves the FIR back to UnderInvestigation. Only the
+// judiciary may call it, and a reason is required.
+func (s *SmartContract) ReturnFinalReport(ctx contractapi.TransactionContextInterface, firID, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("a reason is required to return the final report of FIR %s", firID)
+	}
+	fir, report, submitter, err := readOpenFinalReport(ctx, firID)
+	if err != nil {
+		return err
+	}
+	decidedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	report.Status = ReportReturned
+	report.DecidedBy = submitter
+	report.DecidedAt = decidedAt
+	report.DecisionTxID = ctx.GetStub().GetTxID()
+	report.Remarks = reason
+	if err := putFinalReport(ctx, report); err != nil {
+		return err
+	}
+
+	previousStatus := fir.Status
+	fir.Status = StatusUnderInvestigation
+	fir.OpenReportSeq = 0
+	if err := putFIR(ctx, fir); err != nil {
+		return err
+	}
+	return emitEvent(ctx, EventFIRStatusChanged, FIREvent{FIRID: firID, Station: fir.Station, Status: fir.Status, PreviousStatus: previousStatus})
+}
+
+// GetFinalReports returns every final report submitted on a FIR, oldest first
+func (s *SmartContract) GetFinalReports(ctx contractapi.TransactionContextInterface, firID string) ([]*FinalReport, error) {
+	if _, err := readActiveFIR(ctx, firID); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(finalReportKeyPrefix, []string{firID})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var reports []*FinalReport
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var report FinalReport
+		err = json.Unmarshal(queryResponse.Value, &report)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, &report)
+	}
+	return reports, nil
+}
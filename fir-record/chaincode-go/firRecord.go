@@ -11,7 +11,14 @@ import (
 )
 
 func main() {
-	firChaincode, err := contractapi.NewChaincode(&SmartContract{})
+	firContract := new(SmartContract)
+	firContract.BeforeTransaction = restrictCitizenPortal
+
+	evidenceContract := new(EvidenceContract)
+	evidenceContract.Name = "evidence"
+	evidenceContract.BeforeTransaction = restrictCitizenPortal
+
+	firChaincode, err := contractapi.NewChaincode(firContract, evidenceContract)
 	if err != nil {
 		log.Panicf("Error creating police FIR chaincode: %v", err)
 	}
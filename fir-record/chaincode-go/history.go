@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// firAuditKeyPrefix keys the identity that submitted each write to a FIR: firaudit [firID, txID]
+const firAuditKeyPrefix = "firaudit"
+
+// unknownSubmitter is reported for versions written before submitters were recorded
+const unknownSubmitter = "Unknown"
+
+// FIRAuditRecord records the identity that submitted a transaction writing or deleting a
+// FIR. It is kept apart from the FIR so that deletions are attributed too.
+type FIRAuditRecord struct {
+	FIRID       string `json:"FIRID"`
+	TxID        string `json:"TxID"`
+	MSPID       string `json:"MSPID"`
+	SubmittedBy string `json:"SubmittedBy"`
+}
+
+// putFIRAudit records the submitter of the current transaction against a FIR
+func putFIRAudit(ctx contractapi.TransactionContextInterface, firID, mspid, submitter string) error {
+	txID := ctx.GetStub().GetTxID()
+	key, err := ctx.GetStub().CreateCompositeKey(firAuditKeyPrefix, []string{firID, txID})
+	if err != nil {
+		return err
+	}
+	auditJSON, err := json.Marshal(FIRAuditRecord{FIRID: firID, TxID: txID, MSPID: mspid, SubmittedBy: submitter})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, auditJSON)
+}
+
+// readFIRAudit returns the recorded submitter of a transaction that wrote a FIR, or nil if
+// none was recorded
+func readFIRAudit(ctx contractapi.TransactionContextInterface, firID, txID string) (*FIRAuditRecord, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(firAuditKeyPrefix, []string{firID, txID})
+	if err != nil {
+		return nil, err
+	}
+	auditJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if auditJSON == nil {
+		return nil, nil
+	}
+	var audit FIRAuditRecord
+	if err := json.Unmarshal(auditJSON, &audit); err != nil {
+		return nil, err
+	}
+	return &audit, nil
+}
+
+// FIRHistoryEntry describes one committed version of a FIR
+type FIRHistoryEntry struct {
+	TxID        string `json:"TxID"`
+	Timestamp   string `json:"Timestamp"`
+	MSPID       string `json:"MSPID"`
+	SubmittedBy string `json:"SubmittedBy"`
+	IsDelete    bool   `json:"IsDelete"`
+	FIR         *FIR   `json:"FIR,omitempty" metadata:",optional"`
+
+	// ContentHash is the hex SHA-256 of the FIR exactly as written in this version. Filing
+	// receipts issued by the application gateway carry it so they can be checked later.
+	ContentHash string `json:"ContentHash,omitempty" metadata:",optional"`
+}
+
+// GetFIRHistory returns every committed version of a FIR, oldest first. The submitting
+// MSP and identity come from the audit record of each transaction. Versions written before
+// audit records were kept fall back to the LastModified fields of the stored FIR, and are
+// reported as Unknown where those are empty too, as they are for deletions.
+func (s *SmartContract) GetFIRHistory(ctx contractapi.TransactionContextInterface, firID string) ([]*FIRHistoryEntry, error) {
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(firID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history for FIR %s: %v", firID, err)
+	}
+	defer resultsIterator.Close()
+
+	var history []*FIRHistoryEntry
+	for resultsIterator.HasNext() {
+		modification, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		entry := FIRHistoryEntry{
+			TxID:     modification.TxId,
+			IsDelete: modification.IsDelete,
+		}
+		if ts := modification.Timestamp; ts != nil {
+			entry.Timestamp = time.Unix(ts.Seconds, int64(ts.Nanos)).UTC().Format(time.RFC3339Nano)
+		}
+		if !modification.IsDelete && len(modification.Value) > 0 {
+			var fir FIR
+			err = json.Unmarshal(modification.Value, &fir)
+			if err != nil {
+				return nil, err
+			}
+			entry.FIR = &fir
+			hash := sha256.Sum256(modification.Value)
+			entry.ContentHash = hex.EncodeToString(hash[:])
+			entry.MSPID = fir.LastModifiedMSP
+			entry.SubmittedBy = fir.LastModifiedBy
+		}
+
+		audit, err := readFIRAudit(ctx, firID, modification.TxId)
+		if err != nil {
+			return nil, err
+		}
+		if audit != nil {
+			entry.MSPID = audit.MSPID
+			entry.SubmittedBy = audit.SubmittedBy
+		}
+		if entry.MSPID == "" {
+			entry.MSPID = unknownSubmitter
+		}
+		if entry.SubmittedBy == "" {
+			entry.SubmittedBy = unknownSubmitter
+		}
+		history = append(history, &entry)
+	}
+	if history == nil {
+		return nil, fmt.Errorf("the FIR %s does not exist", firID)
+	}
+
+	// sealed and purged FIRs are only readable through ReadSealedFIR
+	for _, entry := range history {
+		if entry.FIR != nil {
+			if entry.FIR.Seal != nil {
+				return nil, sealedError(entry.FIR)
+			}
+			break
+		}
+	}
+
+	// the peer returns the most recent modification first
+	for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+		history[i], history[j] = history[j], history[i]
+	}
+	return history, nil
+}
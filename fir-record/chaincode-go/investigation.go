@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// IOAssignment records one assignment of an Investigating Officer to a FIR
+type IOAssignment struct {
+	OfficerID         string `json:"OfficerID"`
+	PreviousOfficerID string `json:"PreviousOfficerID"`
+	Reason            string `json:"Reason"`
+	AssignedBy        string `json:"AssignedBy"`
+	AssignedMSP       string `json:"AssignedMSP"`
+	AssignedAt        string `json:"AssignedAt"`
+	TxID              string `json:"TxID"`
+}
+
+// IOCase is a FIR in an officer's caseload
+type IOCase struct {
+	FIR *FIR `json:"FIR,omitempty" metadata:",optional"`
+
+	// NeedsReassignment is set when the officer can no longer investigate the FIR,
+	// because they are not active or are no longer posted to the FIR's station
+	NeedsReassignment  bool   `json:"NeedsReassignment"`
+	ReassignmentReason string `json:"ReassignmentReason,omitempty" metadata:",optional"`
+}
+
+// IOCaseload lists the FIRs assigned to an Investigating Officer
+type IOCaseload struct {
+	OfficerID        string    `json:"OfficerID"`
+	EmploymentStatus string    `json:"EmploymentStatus"`
+	Station          string    `json:"Station"`
+	Cases            []*IOCase `json:"Cases,omitempty" metadata:",optional"`
+}
+
+// AssignIO assigns the first Investigating Officer to a FIR. Only the SHO of the
+// FIR's station may assign, and the officer must be active and posted to that station.
+func (s *SmartContract) AssignIO(ctx contractapi.TransactionContextInterface, firID, officerID, reason string) error {
+	fir, err := readAssignableFIR(ctx, firID)
+	if err != nil {
+		return err
+	}
+	if fir.InvestigatingOfficer != "" {
+		return fmt.Errorf("the FIR %s is already assigned to %s; use ReassignIO", firID, fir.InvestigatingOfficer)
+	}
+	return assignIO(ctx, fir, officerID, reason)
+}
+
+// ReassignIO moves a FIR to a different Investigating Officer, under the same rules as AssignIO.
+// The previous assignment is kept in the FIR's assignment history.
+func (s *SmartContract) ReassignIO(ctx contractapi.TransactionContextInterface, firID, officerID, reason string) error {
+	fir, err := readAssignableFIR(ctx, firID)
+	if err != nil {
+		return err
+	}
+	if fir.InvestigatingOfficer == "" {
+		return fmt.Errorf("the FIR %s has no Investigating Officer; use AssignIO", firID)
+	}
+	if fir.InvestigatingOfficer == officerID {
+		return fmt.Errorf("the FIR %s is already assigned to %s", firID, officerID)
+	}
+	return assignIO(ctx, fir, officerID, reason)
+}
+
+// requireInvestigator enforces that the caller holds one of roles at the FIR's station and,
+// once an IO is assigned, is that IO or the SHO. It returns the caller's officer ID after
+// checking the officer is active and posted to the station. A FIR transferred to another
+// station can no longer be worked on under its old number.
+func requireInvestigator(ctx contractapi.TransactionContextInterface, fir *FIR, roles ...string) (string, error) {
+	if fir.TransferredTo != "" {
+		return "", fmt.Errorf("the FIR %s has been transferred and is now %s", fir.FIRID, fir.TransferredTo)
+	}
+	if err := requireStationRole(ctx, fir.Station, roles...); err != nil {
+		return "", err
+	}
+	officerID, err := getOfficerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if fir.InvestigatingOfficer != "" && officerID != fir.InvestigatingOfficer {
+		if err := requireRole(ctx, RoleSHO); err != nil {
+			return "", fmt.Errorf("%v; the FIR %s is assigned to IO %s", err, fir.FIRID, fir.InvestigatingOfficer)
+		}
+	}
+	if err := validateOfficer(ctx, officerID, fir.Station); err != nil {
+		return "", err
+	}
+	return officerID, nil
+}
+
+// readAssignableFIR reads a FIR whose investigation may be (re)assigned and checks that the
+// caller is an active SHO of its station
+func readAssignableFIR(ctx contractapi.TransactionContextInterface, firID string) (*FIR, error) {
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireStationRole(ctx, fir.Station, RoleSHO); err != nil {
+		return nil, err
+	}
+	supervisorID, err := getOfficerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateOfficer(ctx, supervisorID, fir.Station); err != nil {
+		return nil, err
+	}
+	switch normalizeStatus(fir.Status) {
+	case StatusClosed, StatusTransferred:
+		return nil, fmt.Errorf("the FIR %s is %s and cannot be assigned", firID, fir.Status)
+	}
+	return fir, nil
+}
+
+// assignIO validates the new officer and records the assignment on the FIR
+func assignIO(ctx contractapi.TransactionContextInterface, fir *FIR, officerID, reason string) error {
+	if officerID == "" {
+		return fmt.Errorf("an Investigating Officer is required for FIR %s", fir.FIRID)
+	}
+	if reason == "" {
+		return fmt.Errorf("a reason is required to assign FIR %s", fir.FIRID)
+	}
+	if err := validateOfficer(ctx, officerID, fir.Station); err != nil {
+		return err
+	}
+
+	mspid, _, err := getSubmitter(ctx)
+	if err != nil {
+		return err
+	}
+	supervisorID, err := getOfficerID(ctx)
+	if err != nil {
+		return err
+	}
+	assignedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	fir.IOAssignments = append(fir.IOAssignments, IOAssignment{
+		OfficerID:         officerID,
+		PreviousOfficerID: fir.InvestigatingOfficer,
+		Reason:            reason,
+		AssignedBy:        supervisorID,
+		AssignedMSP:       mspid,
+		AssignedAt:        assignedAt,
+		TxID:              ctx.GetStub().GetTxID(),
+	})
+	fir.InvestigatingOfficer = officerID
+	return putFIR(ctx, fir)
+}
+
+// GetFIRsByIO returns the active FIRs assigned to an Investigating Officer and flags those
+// needing reassignment. Officers may see their own caseload; an SHO may see any officer's
+// caseload at their station. Requires CouchDB.
+func (s *SmartContract) GetFIRsByIO(ctx contractapi.TransactionContextInterface, officerID string) (*IOCaseload, error) {
+	if err := requireRole(ctx, policeRoles...); err != nil {
+		return nil, err
+	}
+	callerID, err := getOfficerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := map[string]interface{}{"InvestigatingOfficer": officerID}
+	if callerID != officerID {
+		if err := requireRole(ctx, RoleSHO); err != nil {
+			return nil, fmt.Errorf("%v; only the SHO may view another officer's caseload", err)
+		}
+		station, err := getAttribute(ctx, attrStation)
+		if err != nil {
+			return nil, err
+		}
+		selector["Station"] = station
+	}
+
+	firs, err := queryFIRs(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	caseload := &IOCaseload{OfficerID: officerID}
+	officer, officerErr := readOfficer(ctx, officerID)
+	if officerErr == nil {
+		caseload.EmploymentStatus = officer.EmploymentStatus
+		caseload.Station = officer.Station
+	}
+
+	for _, fir := range firs {
+		ioCase := &IOCase{FIR: fir}
+		switch {
+		case officerErr != nil:
+			ioCase.ReassignmentReason = officerErr.Error()
+		case officer.EmploymentStatus != employmentStatusActive:
+			ioCase.ReassignmentReason = fmt.Sprintf("officer employment status is %s", officer.EmploymentStatus)
+		case officer.Station != fir.Station:
+			ioCase.ReassignmentReason = fmt.Sprintf("officer is posted to %s", officer.Station)
+		}
+		ioCase.NeedsReassignment = ioCase.ReassignmentReason != ""
+		caseload.Cases = append(caseload.Cases, ioCase)
+	}
+	return caseload, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import "fmt"
+
+// FIR lifecycle states
+const (
+	StatusRegistered         = "Registered"
+	StatusUnderInvestigation = "UnderInvestigation"
+	StatusChargesheetFiled   = "ChargesheetFiled"
+	StatusClosureReportFiled = "ClosureReportFiled"
+	StatusClosed             = "Closed"
+	StatusReopened           = "Reopened"
+
+	// StatusTransferred marks a FIR that has been re-registered at another station; see zerofir.go
+	StatusTransferred = "Transferred"
+)
+
+// firTransitions lists the states the police may move a FIR to from each state.
+// Filing a chargesheet or closure report happens only by submitting the report itself; see
+// finalreport.go. Accepting a closure report and reopening a closed FIR happen only in court;
+// see finalreport.go and courtorder.go. The police close a chargesheeted FIR only once the
+// court has accepted the chargesheet; UpdateFIR checks this.
+var firTransitions = map[string][]string{
+	StatusRegistered:         {StatusUnderInvestigation},
+	StatusUnderInvestigation: {},
+	StatusChargesheetFiled:   {StatusClosed},
+	StatusClosureReportFiled: {},
+	StatusClosed:             {},
+	StatusReopened:           {StatusUnderInvestigation},
+	StatusTransferred:        {},
+}
+
+// legacyStatuses maps free-text statuses written before the lifecycle was enforced
+var legacyStatuses = map[string]string{
+	"Open":          StatusRegistered,
+	"Investigation": StatusUnderInvestigation,
+}
+
+// normalizeStatus returns the lifecycle state for a stored status value
+func normalizeStatus(status string) string {
+	if s, ok := legacyStatuses[status]; ok {
+		return s
+	}
+	return status
+}
+
+// validateTransition checks that a FIR may move from its current status to the requested one
+func validateTransition(firID, current, requested string) error {
+	current = normalizeStatus(current)
+	if _, ok := firTransitions[requested]; !ok {
+		return fmt.Errorf("invalid status %q for FIR %s", requested, firID)
+	}
+	allowed, ok := firTransitions[current]
+	if !ok {
+		return fmt.Errorf("the FIR %s has unrecognised status %q", firID, current)
+	}
+	for _, next := range allowed {
+		if next == requested {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid status transition for FIR %s: cannot move from %s to %s", firID, current, requested)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// firLinkKeyPrefix keys the index of records kept under their own IDs that refer to a
+// FIR: firlink [firID, kind, id]. Transactions that change every record of a FIR walk it
+// with a partial key scan, which the peer re-checks at commit, instead of a rich query,
+// which it does not.
+const firLinkKeyPrefix = "firlink"
+
+// Kinds of record in the FIR link index
+const (
+	linkEvidence = "evidence"
+	linkWarrant  = "warrant"
+	linkBail     = "bail"
+)
+
+// putFIRLink records that the record id of a kind refers to a FIR
+func putFIRLink(ctx contractapi.TransactionContextInterface, firID, kind, id string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(firLinkKeyPrefix, []string{firID, kind, id})
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, []byte{0x00}); err != nil {
+		return fmt.Errorf("failed to link %s %s to FIR %s: %v", kind, id, firID, err)
+	}
+	return nil
+}
+
+// deleteFIRLink removes the link of the record id of a kind to a FIR
+func deleteFIRLink(ctx contractapi.TransactionContextInterface, firID, kind, id string) error {
+	key, err := ctx.GetStub().CreateCompositeKey(firLinkKeyPrefix, []string{firID, kind, id})
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelState(key); err != nil {
+		return fmt.Errorf("failed to unlink %s %s from FIR %s: %v", kind, id, firID, err)
+	}
+	return nil
+}
+
+// firLinks returns the IDs of the records of a kind that refer to a FIR
+func firLinks(ctx contractapi.TransactionContextInterface, firID, kind string) ([]string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(firLinkKeyPrefix, []string{firID, kind})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var ids []string
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, attributes[2])
+	}
+	return ids, nil
+}
+
+// moveFIRLinks moves every link index entry of a FIR to the number it was registered under
+// at another station
+func moveFIRLinks(ctx contractapi.TransactionContextInterface, firID, newFIRID string) error {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(firLinkKeyPrefix, []string{firID})
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return err
+		}
+		if err := putFIRLink(ctx, newFIRID, attributes[1], attributes[2]); err != nil {
+			return err
+		}
+		if err := ctx.GetStub().DelState(queryResponse.Key); err != nil {
+			return fmt.Errorf("failed to move link index entries of FIR %s: %v", firID, err)
+		}
+	}
+	return nil
+}
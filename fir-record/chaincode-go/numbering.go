@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+const firSequenceKeyPrefix = "firseq"
+
+// FIRSequence tracks the last FIR number allocated for a station in a year
+type FIRSequence struct {
+	Station string `json:"Station"`
+	Year    string `json:"Year"`
+	Last    int    `json:"Last"`
+}
+
+// formatFIRNumber renders a FIR number such as MUM-CYB/2025/00042
+func formatFIRNumber(station, year string, seq int) string {
+	return fmt.Sprintf("%s/%s/%05d", station, year, seq)
+}
+
+// allocateFIRNumber returns the next FIR number for a station and year.
+//
+// The counter is read and written in the same transaction as the FIR it numbers.
+// When two filings for the same station race, the peer invalidates the later one
+// with an MVCC read conflict, so neither its counter increment nor its FIR is
+// committed and no number is skipped. The rejected filing must be resubmitted;
+// the application gateway does this automatically.
+func allocateFIRNumber(ctx contractapi.TransactionContextInterface, station, year string) (string, error) {
+	if _, err := strconv.Atoi(year); err != nil || len(year) != 4 {
+		return "", fmt.Errorf("invalid year %q", year)
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(firSequenceKeyPrefix, []string{station, year})
+	if err != nil {
+		return "", err
+	}
+	seqJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	seq := FIRSequence{Station: station, Year: year}
+	if seqJSON != nil {
+		err = json.Unmarshal(seqJSON, &seq)
+		if err != nil {
+			return "", err
+		}
+	}
+	seq.Last++
+
+	seqJSON, err = json.Marshal(seq)
+	if err != nil {
+		return "", err
+	}
+	if err := ctx.GetStub().PutState(key, seqJSON); err != nil {
+		return "", err
+	}
+	return formatFIRNumber(station, year, seq.Last), nil
+}
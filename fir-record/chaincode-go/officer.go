@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/shim"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// policemanChaincode is the name the policeman-record chaincode is deployed under on the same channel
+const policemanChaincode = "policeman"
+
+// employmentStatusActive is the only employment status that allows an officer to act on FIRs
+const employmentStatusActive = "Active"
+
+// officerRecord holds the fields of a policeman-record entry that FIR validation needs
+type officerRecord struct {
+	OfficerID        string `json:"officerId"`
+	Station          string `json:"station"`
+	EmploymentStatus string `json:"employmentStatus"`
+}
+
+// readOfficer fetches an officer from the policeman-record chaincode. The call runs
+// with the caller's identity, so the caller must be allowed to read personnel records.
+func readOfficer(ctx contractapi.TransactionContextInterface, officerID string) (*officerRecord, error) {
+	response := ctx.GetStub().InvokeChaincode(policemanChaincode, [][]byte{[]byte("ReadPolicePersonnel"), []byte(officerID)}, "")
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("unable to verify officer %s with the %s chaincode: %s", officerID, policemanChaincode, response.Message)
+	}
+
+	var officer officerRecord
+	err := json.Unmarshal(response.Payload, &officer)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse officer %s from the %s chaincode: %v", officerID, policemanChaincode, err)
+	}
+	return &officer, nil
+}
+
+// validateOfficer checks that an officer exists, is active and is posted to the given station
+func validateOfficer(ctx contractapi.TransactionContextInterface, officerID, station string) error {
+	officer, err := readOfficer(ctx, officerID)
+	if err != nil {
+		return err
+	}
+	if officer.EmploymentStatus != employmentStatusActive {
+		return fmt.Errorf("the officer %s is not active (employment status %q)", officerID, officer.EmploymentStatus)
+	}
+	if officer.Station != station {
+		return fmt.Errorf("the officer %s is posted to station %q, not %q", officerID, officer.Station, station)
+	}
+	return nil
+}
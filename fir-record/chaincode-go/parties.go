@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Roles a party can have in a FIR
+const (
+	PartyAccused     = "Accused"
+	PartyVictim      = "Victim"
+	PartyComplainant = "Complainant"
+	PartyWitness     = "Witness"
+)
+
+// accusedNameKeyPrefix keys the index of FIRs by accused name: accusedname [name, firID]
+const accusedNameKeyPrefix = "accusedname"
+
+// partyTransientKey is the transient map key AddParty and UpdateParty read the party from
+const partyTransientKey = "party"
+
+// Party is a person named in a FIR. Accused are recorded in the public FIR; victims,
+// complainants and witnesses only in the FIR's private details.
+type Party struct {
+	PartyNo int    `json:"PartyNo"`
+	Role    string `json:"Role"`
+
+	// Unknown marks an accused who has not been identified yet; Name may then be empty
+	Unknown bool     `json:"Unknown,omitempty" metadata:",optional"`
+	Name    string   `json:"Name"`
+	Aliases []string `json:"Aliases,omitempty" metadata:",optional"`
+	Age     int      `json:"Age,omitempty" metadata:",optional"`
+	Gender  string   `json:"Gender"`
+	Address string   `json:"Address"`
+
+	// PersonID is an optional reference to an identity document or person register entry
+	PersonID string `json:"PersonID,omitempty" metadata:",optional"`
+
+	AddedBy   string `json:"AddedBy"`
+	AddedAt   string `json:"AddedAt"`
+	UpdatedBy string `json:"UpdatedBy,omitempty" metadata:",optional"`
+	UpdatedAt string `json:"UpdatedAt,omitempty" metadata:",optional"`
+}
+
+// validateParty checks the fields a caller supplies for a party
+func validateParty(party *Party) error {
+	switch party.Role {
+	case PartyAccused, PartyVictim, PartyComplainant, PartyWitness:
+	default:
+		return fmt.Errorf("invalid party role %q", party.Role)
+	}
+	if party.Unknown && party.Role != PartyAccused {
+		return fmt.Errorf("only an accused may be recorded as unknown")
+	}
+	if party.Name == "" && !party.Unknown {
+		return fmt.Errorf("a %s requires a name unless recorded as unknown", party.Role)
+	}
+	if party.Age < 0 {
+		return fmt.Errorf("invalid age %d for %s %s", party.Age, party.Role, party.Name)
+	}
+	return nil
+}
+
+// convertLegacyAccused turns the single Accused string of FIRs filed before structured
+// parties into an accused party. The FIR is stored in the new form the next time it is written.
+func convertLegacyAccused(fir *FIR) {
+	if fir.Accused == "" {
+		return
+	}
+	if len(fir.Parties) == 0 {
+		fir.PartyCount++
+		fir.Parties = []Party{{
+			PartyNo: fir.PartyCount,
+			Role:    PartyAccused,
+			Name:    fir.Accused,
+			AddedBy: fir.FiledBy,
+			AddedAt: fir.Timestamp,
+		}}
+	}
+	fir.Accused = ""
+}
+
+// addParty validates a party and numbers it. Accused are recorded on the FIR; other
+// parties are listed in PrivateParties and returned for the caller to store privately.
+func addParty(fir *FIR, party Party, addedBy, addedAt string) (*Party, error) {
+	if err := validateParty(&party); err != nil {
+		return nil, err
+	}
+	fir.PartyCount++
+	party.PartyNo = fir.PartyCount
+	party.AddedBy = addedBy
+	party.AddedAt = addedAt
+	party.UpdatedBy = ""
+	party.UpdatedAt = ""
+	markJuvenile(fir, &party)
+
+	if party.Role == PartyAccused {
+		fir.Parties = append(fir.Parties, party)
+	} else {
+		fir.PrivateParties = append(fir.PrivateParties, PrivatePartyRef{
+			PartyNo: party.PartyNo,
+			Role:    party.Role,
+			AddedBy: addedBy,
+			AddedAt: addedAt,
+		})
+	}
+	return &party, nil
+}
+
+// readTransientParty reads the party passed in the transient map
+func readTransientParty(ctx contractapi.TransactionContextInterface) (*Party, error) {
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transient map: %v", err)
+	}
+	partyJSON, ok := transientMap[partyTransientKey]
+	if !ok {
+		return nil, fmt.Errorf("the party must be passed in the transient map under %q", partyTransientKey)
+	}
+
+	var party Party
+	err = json.Unmarshal(partyJSON, &party)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s transient data: %v", partyTransientKey, err)
+	}
+	return &party, nil
+}
+
+// readWritableFIR reads a FIR whose parties are to be changed
+func readWritableFIR(ctx contractapi.TransactionContextInterface, firID string) (*FIR, error) {
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return nil, err
+	}
+	if fir.TransferredTo != "" {
+		return nil, fmt.Errorf("the FIR %s has been transferred and is now %s", firID, fir.TransferredTo)
+	}
+	return fir, nil
+}
+
+// AddParty adds a party identified after filing, such as a newly traced suspect, to a FIR
+// and returns its party number. The party is passed in the transient map under "party" so
+// victim and witness details never appear in transaction arguments; those are stored under
+// a key of their own with the random salt passed under "salt".
+func (s *SmartContract) AddParty(ctx contractapi.TransactionContextInterface, firID string) (int, error) {
+	fir, err := readWritableFIR(ctx, firID)
+	if err != nil {
+		return 0, err
+	}
+	officerID, err := requireInvestigator(ctx, fir, RoleSHO, RoleIO)
+	if err != nil {
+		return 0, err
+	}
+	party, err := readTransientParty(ctx)
+	if err != nil {
+		return 0, err
+	}
+	addedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return 0, err
+	}
+	salt := ""
+	if party.Role != PartyAccused {
+		if salt, err = readTransientSalt(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	added, err := addParty(fir, *party, officerID, addedAt)
+	if err != nil {
+		return 0, err
+	}
+	if added.Role != PartyAccused {
+		ref := &fir.PrivateParties[len(fir.PrivateParties)-1]
+		if err := putPrivateParty(ctx, fir, ref, added, salt); err != nil {
+			return 0, err
+		}
+	}
+	return added.PartyNo, putFIR(ctx, fir)
+}
+
+// UpdateParty replaces the details of a party, passed in the transient map under "party".
+// The party keeps its number and role. A victim, complainant or witness is rewritten under
+// its own key with the random salt passed under "salt".
+func (s *SmartContract) UpdateParty(ctx contractapi.TransactionContextInterface, firID string, partyNo int) error {
+	fir, err := readWritableFIR(ctx, firID)
+	if err != nil {
+		return err
+	}
+	officerID, err := requireInvestigator(ctx, fir, RoleSHO, RoleIO)
+	if err != nil {
+		return err
+	}
+	party, err := readTransientParty(ctx)
+	if err != nil {
+		return err
+	}
+	if err := validateParty(party); err != nil {
+		return err
+	}
+	updatedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	if party.Role == PartyAccused {
+		for i := range fir.Parties {
+			if fir.Parties[i].PartyNo != partyNo {
+				continue
+			}
+			party.PartyNo = partyNo
+			party.AddedBy = fir.Parties[i].AddedBy
+			party.AddedAt = fir.Parties[i].AddedAt
+			party.UpdatedBy = officerID
+			party.UpdatedAt = updatedAt
+			fir.Parties[i] = *party
+			markJuvenile(fir, party)
+			return putFIR(ctx, fir)
+		}
+	} else {
+		for i := range fir.PrivateParties {
+			ref := &fir.PrivateParties[i]
+			if ref.PartyNo != partyNo {
+				continue
+			}
+			if ref.Role != party.Role {
+				return fmt.Errorf("party %d of FIR %s is a %s; the role of a party cannot be changed", partyNo, firID, ref.Role)
+			}
+			salt, err := readTransientSalt(ctx)
+			if err != nil {
+				return err
+			}
+			party.PartyNo = partyNo
+			party.AddedBy = ref.AddedBy
+			party.AddedAt = ref.AddedAt
+			party.UpdatedBy = officerID
+			party.UpdatedAt = updatedAt
+			ref.UpdatedBy = officerID
+			ref.UpdatedAt = updatedAt
+			markJuvenile(fir, party)
+			if err := putPrivateParty(ctx, fir, ref, party, salt); err != nil {
+				return err
+			}
+			return putFIR(ctx, fir)
+		}
+	}
+	return fmt.Errorf("the FIR %s has no %s with party number %d", firID, party.Role, partyNo)
+}
+
+// normalizeName folds a name or alias to lower case with single spaces, so lookups by
+// accused name ignore case and spacing
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// accusedNames returns the distinct normalised names and aliases of a FIR's identified
+// accused, sorted
+func accusedNames(fir *FIR) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, party := range fir.Parties {
+		if party.Role != PartyAccused {
+			continue
+		}
+		for _, name := range append([]string{party.Name}, party.Aliases...) {
+			name = normalizeName(name)
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// indexAccusedNames brings the accused name index of a FIR in step with its parties.
+// fir.AccusedNames holds the names indexed when the FIR was last written, so index keys
+// for names that have since been changed or removed are deleted. Every current name is
+// written again, which also indexes a FIR stored under a new number by a transfer.
+func indexAccusedNames(ctx contractapi.TransactionContextInterface, fir *FIR) error {
+	names := accusedNames(fir)
+	current := make(map[string]bool, len(names))
+	for _, name := range names {
+		current[name] = true
+	}
+
+	for _, name := range fir.AccusedNames {
+		if current[name] {
+			continue
+		}
+		key, err := ctx.GetStub().CreateCompositeKey(accusedNameKeyPrefix, []string{name, fir.FIRID})
+		if err != nil {
+			return err
+		}
+		if err := ctx.GetStub().DelState(key); err != nil {
+			return fmt.Errorf("failed to delete accused index entry for FIR %s: %v", fir.FIRID, err)
+		}
+	}
+	for _, name := range names {
+		key, err := ctx.GetStub().CreateCompositeKey(accusedNameKeyPrefix, []string{name, fir.FIRID})
+		if err != nil {
+			return err
+		}
+		if err := ctx.GetStub().PutState(key, []byte{0x00}); err != nil {
+			return fmt.Errorf("failed to index accused of FIR %s: %v", fir.FIRID, err)
+		}
+	}
+
+	fir.AccusedNames = names
+	return nil
+}
+
+// deleteAccusedIndex removes every accused name index entry of a FIR
+func deleteAccusedIndex(ctx contractapi.TransactionContextInterface, fir *FIR) error {
+	for _, name := range append(accusedNames(fir), fir.AccusedNames...) {
+		key, err := ctx.GetStub().CreateCompositeKey(accusedNameKeyPrefix, []string{name, fir.FIRID})
+		if err != nil {
+			return err
+		}
+		if err := ctx.GetStub().DelState(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+const (
+	// firPrivateCollection holds complainant, victim and witness details; see collections_config.json.
+	// Only Org1 may read or write it.
+	firPrivateCollection = "firPrivateDetails"
+
+	// firPrivateTransientKey is the transient map key FileFIR reads private details from
+	firPrivateTransientKey = "fir_private"
+
+	// privateSaltTransientKey is the transient map key of the random salt stored in every
+	// private record, so the hashes on the ledger cannot be matched against guessed details.
+	// The client supplies it because every endorser must store the same bytes.
+	privateSaltTransientKey = "salt"
+	minPrivateSaltLength    = 32
+
+	// privatePartyKeyPrefix keys parties added or updated after filing in the collection:
+	// firparty [privateDetailsID, partyNo]
+	privatePartyKeyPrefix = "firparty"
+)
+
+// FIRPrivateDetails holds the personally identifying parts of a FIR.
+// It is stored only on Org1 peers; world state keeps its hash.
+type FIRPrivateDetails struct {
+	FIRID string `json:"FIRID"`
+
+	// Victims, complainants and witnesses; see parties.go
+	Parties []Party `json:"Parties,omitempty" metadata:",optional"`
+
+	// Complainant, Victim and Witnesses are the free-text fields of FIRs filed before structured parties
+	Complainant string   `json:"Complainant"`
+	Victim      string   `json:"Victim"`
+	Witnesses   []string `json:"Witnesses,omitempty" metadata:",optional"`
+
+	ContactDetails string `json:"ContactDetails"`
+	Statement      string `json:"Statement"`
+
+	Salt string `json:"Salt,omitempty" metadata:",optional"`
+}
+
+// PrivateParty is a victim, complainant or witness added or updated after filing. Each is
+// stored under its own key so that changing one never reads the collection, which only
+// Org1 peers hold.
+type PrivateParty struct {
+	FIRID string `json:"FIRID"`
+	Party Party  `json:"Party"`
+	Salt  string `json:"Salt"`
+}
+
+// PrivatePartyRef records a private party on the public FIR without its details
+type PrivatePartyRef struct {
+	PartyNo int    `json:"PartyNo"`
+	Role    string `json:"Role"`
+
+	// Hash is the SHA-256 of the party's own private record, or empty while the party
+	// is held in the private details recorded at filing
+	Hash string `json:"Hash,omitempty" metadata:",optional"`
+
+	AddedBy   string `json:"AddedBy"`
+	AddedAt   string `json:"AddedAt"`
+	UpdatedBy string `json:"UpdatedBy,omitempty" metadata:",optional"`
+	UpdatedAt string `json:"UpdatedAt,omitempty" metadata:",optional"`
+}
+
+// canReadPrivateDetails reports whether the caller is the SHO or an IO of the FIR's station
+func canReadPrivateDetails(ctx contractapi.TransactionContextInterface, fir *FIR) bool {
+	return requireStationRole(ctx, fir.Station, RoleSHO, RoleIO) == nil
+}
+
+// readTransientPrivateDetails returns the private details passed to FileFIR in the
+// transient map, or nil if there are none
+func readTransientPrivateDetails(ctx contractapi.TransactionContextInterface) (*FIRPrivateDetails, error) {
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transient map: %v", err)
+	}
+	privateJSON, ok := transientMap[firPrivateTransientKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var details FIRPrivateDetails
+	err = json.Unmarshal(privateJSON, &details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s transient data: %v", firPrivateTransientKey, err)
+	}
+	return &details, nil
+}
+
+// readTransientSalt returns the salt passed in the transient map under "salt"
+func readTransientSalt(ctx contractapi.TransactionContextInterface) (string, error) {
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return "", fmt.Errorf("failed to read transient map: %v", err)
+	}
+	salt := string(transientMap[privateSaltTransientKey])
+	if len(salt) < minPrivateSaltLength {
+		return "", fmt.Errorf("a random salt of at least %d characters must be passed in the transient map under %q", minPrivateSaltLength, privateSaltTransientKey)
+	}
+	return salt, nil
+}
+
+// privateDetailsID returns the key a FIR's private details are stored under, which is the
+// FIR number it was filed under
+func privateDetailsID(fir *FIR) string {
+	if fir.PrivateDetailsID != "" {
+		return fir.PrivateDetailsID
+	}
+	return fir.FIRID
+}
+
+// hasPrivateDetails reports whether anything about the FIR is held in the collection
+func hasPrivateDetails(fir *FIR) bool {
+	if fir.PrivateDetailsHash != "" {
+		return true
+	}
+	for _, ref := range fir.PrivateParties {
+		if ref.Hash != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// putPrivateRecord stores a record in the collection and returns the hex SHA-256 of the
+// stored bytes
+func putPrivateRecord(ctx contractapi.TransactionContextInterface, key string, record interface{}) (string, error) {
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	if err := ctx.GetStub().PutPrivateData(firPrivateCollection, key, recordJSON); err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(recordJSON)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// getPrivateRecord reads a record from the collection and checks it against its hash on
+// the ledger. It reports false if this peer does not hold the collection.
+func getPrivateRecord(ctx contractapi.TransactionContextInterface, key, hash string, record interface{}) (bool, error) {
+	recordJSON, err := ctx.GetStub().GetPrivateData(firPrivateCollection, key)
+	if err != nil {
+		return false, err
+	}
+	if recordJSON == nil {
+		return false, nil
+	}
+
+	sum := sha256.Sum256(recordJSON)
+	if hex.EncodeToString(sum[:]) != hash {
+		return false, fmt.Errorf("the private record does not match the hash on the ledger")
+	}
+	return true, json.Unmarshal(recordJSON, record)
+}
+
+// putFIRPrivateDetails stores the private details of a FIR being filed and records the
+// hash of the stored bytes on the FIR
+func putFIRPrivateDetails(ctx contractapi.TransactionContextInterface, fir *FIR, details *FIRPrivateDetails, salt string) error {
+	details.FIRID = fir.FIRID
+	details.Salt = salt
+
+	hash, err := putPrivateRecord(ctx, fir.FIRID, details)
+	if err != nil {
+		return fmt.Errorf("failed to put private details for FIR %s: %v", fir.FIRID, err)
+	}
+	fir.PrivateDetailsHash = hash
+	return nil
+}
+
+// privatePartyKey returns the collection key of a party added or updated after filing
+func privatePartyKey(ctx contractapi.TransactionContextInterface, fir *FIR, partyNo int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(privatePartyKeyPrefix, []string{privateDetailsID(fir), fmt.Sprintf("%06d", partyNo)})
+}
+
+// putPrivateParty stores a private party under its own key and records its hash on the
+// party's entry in the FIR
+func putPrivateParty(ctx contractapi.TransactionContextInterface, fir *FIR, ref *PrivatePartyRef, party *Party, salt string) error {
+	key, err := privatePartyKey(ctx, fir, party.PartyNo)
+	if err != nil {
+		return err
+	}
+	hash, err := putPrivateRecord(ctx, key, PrivateParty{FIRID: privateDetailsID(fir), Party: *party, Salt: salt})
+	if err != nil {
+		return fmt.Errorf("failed to put party %d of FIR %s: %v", party.PartyNo, fir.FIRID, err)
+	}
+	ref.Hash = hash
+	return nil
+}
+
+// getFIRPrivateDetails reads the private details of a FIR, with the parties added or
+// updated since filing, and checks each record against its hash on the ledger. It returns
+// nil if the FIR has none or this peer does not hold the collection.
+func getFIRPrivateDetails(ctx contractapi.TransactionContextInterface, fir *FIR) (*FIRPrivateDetails, error) {
+	if !hasPrivateDetails(fir) {
+		return nil, nil
+	}
+
+	details := &FIRPrivateDetails{FIRID: fir.FIRID}
+	if fir.PrivateDetailsHash != "" {
+		found, err := getPrivateRecord(ctx, privateDetailsID(fir), fir.PrivateDetailsHash, details)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private details for FIR %s: %v", fir.FIRID, err)
+		}
+		if !found {
+			return nil, nil
+		}
+		details.FIRID = fir.FIRID
+	}
+
+	for _, ref := range fir.PrivateParties {
+		if ref.Hash == "" {
+			continue
+		}
+		key, err := privatePartyKey(ctx, fir, ref.PartyNo)
+		if err != nil {
+			return nil, err
+		}
+		var private PrivateParty
+		found, err := getPrivateRecord(ctx, key, ref.Hash, &private)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read party %d of FIR %s: %v", ref.PartyNo, fir.FIRID, err)
+		}
+		if !found {
+			return nil, nil
+		}
+		replaced := false
+		for i := range details.Parties {
+			if details.Parties[i].PartyNo == ref.PartyNo {
+				details.Parties[i] = private.Party
+				replaced = true
+			}
+		}
+		if !replaced {
+			details.Parties = append(details.Parties, private.Party)
+		}
+	}
+	sort.Slice(details.Parties, func(i, j int) bool {
+		return details.Parties[i].PartyNo < details.Parties[j].PartyNo
+	})
+	return details, nil
+}
+
+// purgeFIRPrivateDetails purges a FIR's private details and the parties added or updated
+// since filing from the collection and its history on every peer
+func purgeFIRPrivateDetails(ctx contractapi.TransactionContextInterface, fir *FIR) error {
+	if fir.PrivateDetailsHash != "" {
+		if err := ctx.GetStub().PurgePrivateData(firPrivateCollection, privateDetailsID(fir)); err != nil {
+			return fmt.Errorf("failed to purge private details for FIR %s: %v", fir.FIRID, err)
+		}
+	}
+	for _, ref := range fir.PrivateParties {
+		if ref.Hash == "" {
+			continue
+		}
+		key, err := privatePartyKey(ctx, fir, ref.PartyNo)
+		if err != nil {
+			return err
+		}
+		if err := ctx.GetStub().PurgePrivateData(firPrivateCollection, key); err != nil {
+			return fmt.Errorf("failed to purge party %d of FIR %s: %v", ref.PartyNo, fir.FIRID, err)
+		}
+	}
+	return nil
+}
+
+// mergeFIRPrivateDetails attaches the private details to a FIR for authorised callers
+// and marks the FIR as redacted for everyone else
+func mergeFIRPrivateDetails(ctx contractapi.TransactionContextInterface, fir *FIR) error {
+	if !hasPrivateDetails(fir) {
+		return nil
+	}
+
+	// a transferred FIR's private details are read, and may have been updated, under its new number
+	if fir.TransferredTo != "" || !canReadPrivateDetails(ctx, fir) {
+		fir.Redacted = true
+		return nil
+	}
+
+	details, err := getFIRPrivateDetails(ctx, fir)
+	if err != nil {
+		return err
+	}
+	if details == nil {
+		// this peer does not hold the collection
+		fir.Redacted = true
+		return nil
+	}
+	fir.PrivateDetails = details
+	return nil
+}
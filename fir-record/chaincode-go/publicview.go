@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// juvenileAge is the age below which a party is a juvenile
+const juvenileAge = 18
+
+// sensitiveCrimeTypes lists crime heads, in lower case, whose FIRs are withheld from the public view
+var sensitiveCrimeTypes = map[string]bool{
+	"rape":                 true,
+	"sexual assault":       true,
+	"sexual harassment":    true,
+	"sexual offence":       true,
+	"outraging modesty":    true,
+	"voyeurism":            true,
+	"stalking":             true,
+	"pocso":                true,
+	"child sexual abuse":   true,
+	"child sexual offence": true,
+}
+
+// PublicFIR is the redacted projection of a FIR that citizens may see
+type PublicFIR struct {
+	FIRID     string `json:"FIRID"`
+	Station   string `json:"Station"`
+	Date      string `json:"Date"`
+	CrimeType string `json:"CrimeType"`
+	Status    string `json:"Status"`
+
+	// Accused lists the initials of each accused, or "Unidentified"
+	Accused []string `json:"Accused"`
+
+	// TransferredTo is the FIR number the case continues under at another station
+	TransferredTo string `json:"TransferredTo,omitempty" metadata:",optional"`
+}
+
+// markJuvenile flags a FIR once a party is recorded as under juvenileAge
+func markJuvenile(fir *FIR, party *Party) {
+	if party.Age > 0 && party.Age < juvenileAge {
+		fir.Juvenile = true
+	}
+}
+
+// isSensitive reports whether a FIR must be suppressed from the public view entirely
+func isSensitive(fir *FIR) bool {
+	return fir.Juvenile || sensitiveCrimeTypes[strings.ToLower(strings.TrimSpace(fir.CrimeType))]
+}
+
+// maskName reduces a name to its initials, e.g. "Alex Murphy" to "A. M."
+func maskName(name string) string {
+	var initials []string
+	for _, word := range strings.Fields(name) {
+		initials = append(initials, string([]rune(word)[0])+".")
+	}
+	return strings.Join(initials, " ")
+}
+
+// PublicFIRView returns the redacted public view of a FIR: its number, station, date,
+// crime head and status, with the accused reduced to initials and no victim, complainant
+// or witness details. Sealed FIRs, sexual offences and FIRs involving a juvenile are
+// reported as unavailable exactly as a FIR that does not exist, so their existence is
+// not disclosed. Any organisation, including the citizen portal, may call it.
+func (s *SmartContract) PublicFIRView(ctx contractapi.TransactionContextInterface, firID string) (*PublicFIR, error) {
+	unavailable := fmt.Errorf("the FIR %s is not available for public view", firID)
+	exists, err := s.FIRExists(ctx, firID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, unavailable
+	}
+	fir, err := readFIR(ctx, firID)
+	if err != nil {
+		return nil, err
+	}
+	if fir.Seal != nil || isSensitive(fir) {
+		return nil, unavailable
+	}
+
+	view := PublicFIR{
+		FIRID:         fir.FIRID,
+		Station:       fir.Station,
+		Date:          fir.Timestamp,
+		CrimeType:     fir.CrimeType,
+		Status:        normalizeStatus(fir.Status),
+		Accused:       []string{},
+		TransferredTo: fir.TransferredTo,
+	}
+	if len(view.Date) >= 10 {
+		view.Date = view.Date[:10]
+	}
+	for _, party := range fir.Parties {
+		if party.Role != PartyAccused {
+			continue
+		}
+		if party.Unknown || party.Name == "" {
+			view.Accused = append(view.Accused, "Unidentified")
+		} else {
+			view.Accused = append(view.Accused, maskName(party.Name))
+		}
+	}
+	return &view, nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// The query functions below use CouchDB selectors and therefore require the
+// peers to run with CouchDB as the state database (compose-couch). Matching
+// indexes ship under META-INF/statedb/couchdb/indexes.
+
+// QueryFIRsByAccused returns all FIRs naming the given accused, by name or alias, ignoring
+// case and spacing. FIRs are found through the accused name index kept by putFIR; FIRs
+// filed with a single Accused string and not written since are found with a rich query.
+func (s *SmartContract) QueryFIRsByAccused(ctx contractapi.TransactionContextInterface, accused string) ([]*FIR, error) {
+	name := normalizeName(accused)
+	if name == "" {
+		return nil, fmt.Errorf("an accused name is required")
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(accusedNameKeyPrefix, []string{name})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var firs []*FIR
+	seen := make(map[string]bool)
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		fir, err := readFIR(ctx, attributes[1])
+		if err != nil {
+			return nil, err
+		}
+		seen[fir.FIRID] = true
+		if fir.Seal == nil {
+			firs = append(firs, fir)
+		}
+	}
+
+	legacy, err := queryFIRs(ctx, map[string]interface{}{"Accused": accused})
+	if err != nil {
+		return nil, err
+	}
+	for _, fir := range legacy {
+		if !seen[fir.FIRID] {
+			firs = append(firs, fir)
+		}
+	}
+	return firs, nil
+}
+
+// QueryFIRsByCrimeType returns all FIRs registered under the given crime type
+func (s *SmartContract) QueryFIRsByCrimeType(ctx contractapi.TransactionContextInterface, crimeType string) ([]*FIR, error) {
+	return queryFIRs(ctx, map[string]interface{}{"CrimeType": crimeType})
+}
+
+// QueryFIRsByStatus returns all FIRs currently in the given lifecycle status, including
+// FIRs stored under a legacy status that maps to it, such as Open for Registered
+func (s *SmartContract) QueryFIRsByStatus(ctx contractapi.TransactionContextInterface, status string) ([]*FIR, error) {
+	status = normalizeStatus(status)
+	statuses := []string{status}
+	for legacy, current := range legacyStatuses {
+		if current == status {
+			statuses = append(statuses, legacy)
+		}
+	}
+	sort.Strings(statuses[1:])
+	return queryFIRs(ctx, map[string]interface{}{"Status": map[string]interface{}{"$in": statuses}})
+}
+
+// QueryFIRsByDateRange returns all FIRs filed between startDate and endDate inclusive.
+// Dates are RFC 3339 strings and compare lexically, so a bare date such as
+// "2024-01-31" as endDate excludes FIRs filed later that day; use "2024-01-31T23:59:59Z".
+func (s *SmartContract) QueryFIRsByDateRange(ctx contractapi.TransactionContextInterface, startDate, endDate string) ([]*FIR, error) {
+	if startDate > endDate {
+		return nil, fmt.Errorf("start date %s is after end date %s", startDate, endDate)
+	}
+	return queryFIRs(ctx, map[string]interface{}{
+		"Timestamp": map[string]interface{}{"$gte": startDate, "$lte": endDate},
+	})
+}
+
+// queryFIRs runs a CouchDB selector restricted to FIR documents that have not been sealed.
+// FIRs written before DocType was recorded have none, so they are recognised by the FIRID
+// and CrimeType fields that no other document carries; they gain a DocType when next written.
+func queryFIRs(ctx contractapi.TransactionContextInterface, selector map[string]interface{}) ([]*FIR, error) {
+	selector["$or"] = []interface{}{
+		map[string]interface{}{"DocType": firDocType},
+		map[string]interface{}{
+			"DocType":   map[string]interface{}{"$exists": false},
+			"FIRID":     map[string]interface{}{"$exists": true},
+			"CrimeType": map[string]interface{}{"$exists": true},
+		},
+	}
+	selector["Seal"] = map[string]interface{}{"$exists": false}
+	queryJSON, err := json.Marshal(map[string]interface{}{"selector": selector})
+	if err != nil {
+		return nil, err
+	}
+	return getQueryResultForQueryString(ctx, string(queryJSON))
+}
+
+// getQueryResultForQueryString executes a rich query and unmarshals the matching FIRs
+func getQueryResultForQueryString(ctx contractapi.TransactionContextInterface, queryString string) ([]*FIR, error) {
+	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run query: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var firs []*FIR
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var fir FIR
+		err = json.Unmarshal(queryResponse.Value, &fir)
+		if err != nil {
+			return nil, err
+		}
+		convertLegacyAccused(&fir)
+		firs = append(firs, &fir)
+	}
+	return firs, nil
+}
+
+// PaginatedQueryResult holds one page of FIRs and the bookmark for the next page
+type PaginatedQueryResult struct {
+	Records             []*FIR `json:"Records,omitempty" metadata:",optional"`
+	FetchedRecordsCount int32  `json:"FetchedRecordsCount"`
+	Bookmark            string `json:"Bookmark"`
+}
+
+// GetFIRsWithPagination returns up to pageSize FIRs starting at bookmark.
+// Pass an empty bookmark for the first page; an empty bookmark in the result means there are no more pages.
+// Sealed FIRs are counted in FetchedRecordsCount but left out of Records.
+func (s *SmartContract) GetFIRsWithPagination(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*PaginatedQueryResult, error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var firs []*FIR
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var fir FIR
+		err = json.Unmarshal(queryResponse.Value, &fir)
+		if err != nil {
+			return nil, err
+		}
+		if fir.Seal != nil {
+			continue
+		}
+		convertLegacyAccused(&fir)
+		firs = append(firs, &fir)
+	}
+
+	return &PaginatedQueryResult{
+		Records:             firs,
+		FetchedRecordsCount: metadata.FetchedRecordsCount,
+		Bookmark:            metadata.Bookmark,
+	}, nil
+}
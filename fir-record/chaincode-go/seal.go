@@ -0,0 +1,333 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/v2/pkg/statebased"
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Sealed FIR dispositions
+const (
+	DispositionWithdrawn = "Withdrawn"
+	DispositionExpunged  = "Expunged"
+)
+
+const sealAccessKeyPrefix = "sealaccess"
+
+// SealRecord explains why a FIR was withdrawn or expunged
+type SealRecord struct {
+	Disposition string `json:"Disposition"`
+	Reason      string `json:"Reason"`
+	OrderRef    string `json:"OrderRef"`
+	SealedBy    string `json:"SealedBy"`
+	SealedMSP   string `json:"SealedMSP"`
+	SealedAt    string `json:"SealedAt"`
+}
+
+// SealedFIRAccess records one read of a sealed FIR
+type SealedFIRAccess struct {
+	FIRID      string `json:"FIRID"`
+	TxID       string `json:"TxID"`
+	AccessedBy string `json:"AccessedBy"`
+	AccessMSP  string `json:"AccessMSP"`
+	AccessedAt string `json:"AccessedAt"`
+	Purpose    string `json:"Purpose"`
+}
+
+func sealedError(fir *FIR) error {
+	return fmt.Errorf("the FIR %s has been sealed (%s under order %s) and is only readable through ReadSealedFIR", fir.FIRID, fir.Seal.Disposition, fir.Seal.OrderRef)
+}
+
+// requireJudiciaryOrSHO enforces access for the judiciary and the SHO of the given station
+func requireJudiciaryOrSHO(ctx contractapi.TransactionContextInterface, station string) error {
+	mspid, err := getMSPID(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get MSP ID: %v", err)
+	}
+	if mspid == judiciaryMSP {
+		return nil
+	}
+	return requireStationRole(ctx, station, RoleSHO)
+}
+
+// SealFIR marks a FIR as withdrawn or expunged under the given order. The FIR is hidden
+// from normal reads and queries, and from then on any change to it, including PurgeFIR,
+// must be endorsed by the judiciary's peers as well as the police's.
+func (s *SmartContract) SealFIR(ctx contractapi.TransactionContextInterface, firID, disposition, reason, orderRef string) error {
+	if disposition != DispositionWithdrawn && disposition != DispositionExpunged {
+		return fmt.Errorf("invalid disposition %q: must be %s or %s", disposition, DispositionWithdrawn, DispositionExpunged)
+	}
+	if reason == "" || orderRef == "" {
+		return fmt.Errorf("sealing FIR %s requires a reason and an order reference", firID)
+	}
+
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return err
+	}
+	if err := requireStationRole(ctx, fir.Station, RoleSHO); err != nil {
+		return err
+	}
+
+	mspid, submitter, err := getSubmitter(ctx)
+	if err != nil {
+		return err
+	}
+	sealedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	fir.Seal = &SealRecord{
+		Disposition: disposition,
+		Reason:      reason,
+		OrderRef:    orderRef,
+		SealedBy:    submitter,
+		SealedMSP:   mspid,
+		SealedAt:    sealedAt,
+	}
+	if err := putFIR(ctx, fir); err != nil {
+		return err
+	}
+
+	endorsementPolicy, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return err
+	}
+	err = endorsementPolicy.AddOrgs(statebased.RoleTypePeer, "Org1MSP", judiciaryMSP)
+	if err != nil {
+		return err
+	}
+	policy, err := endorsementPolicy.Policy()
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().SetStateValidationParameter(firID, policy); err != nil {
+		return err
+	}
+	return emitEvent(ctx, EventFIRSealed, FIREvent{FIRID: firID, Station: fir.Station, Status: fir.Status, Disposition: disposition, OrderRef: orderRef})
+}
+
+// ReadSealedFIR returns a sealed FIR and records who read it and why. It must be
+// submitted rather than evaluated so that the access record is committed.
+func (s *SmartContract) ReadSealedFIR(ctx contractapi.TransactionContextInterface, firID, purpose string) (*FIR, error) {
+	if purpose == "" {
+		return nil, fmt.Errorf("reading sealed FIR %s requires a purpose", firID)
+	}
+
+	fir, err := readFIR(ctx, firID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireJudiciaryOrSHO(ctx, fir.Station); err != nil {
+		return nil, err
+	}
+	if fir.Seal == nil {
+		return nil, fmt.Errorf("the FIR %s is not sealed; use ReadFIR", firID)
+	}
+
+	mspid, submitter, err := getSubmitter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accessedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	access := SealedFIRAccess{
+		FIRID:      firID,
+		TxID:       ctx.GetStub().GetTxID(),
+		AccessedBy: submitter,
+		AccessMSP:  mspid,
+		AccessedAt: accessedAt,
+		Purpose:    purpose,
+	}
+	accessJSON, err := json.Marshal(access)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(sealAccessKeyPrefix, []string{firID, access.TxID})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(key, accessJSON); err != nil {
+		return nil, err
+	}
+
+	if err := mergeFIRPrivateDetails(ctx, fir); err != nil {
+		return nil, err
+	}
+	return fir, nil
+}
+
+// GetSealedFIRAccessLog returns every recorded read of a sealed FIR. Once a FIR has
+// been purged only the judiciary can read its access log.
+func (s *SmartContract) GetSealedFIRAccessLog(ctx contractapi.TransactionContextInterface, firID string) ([]*SealedFIRAccess, error) {
+	station := ""
+	if fir, err := readFIR(ctx, firID); err == nil {
+		station = fir.Station
+	}
+	if err := requireJudiciaryOrSHO(ctx, station); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(sealAccessKeyPrefix, []string{firID})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var accessLog []*SealedFIRAccess
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var access SealedFIRAccess
+		err = json.Unmarshal(queryResponse.Value, &access)
+		if err != nil {
+			return nil, err
+		}
+		accessLog = append(accessLog, &access)
+	}
+	return accessLog, nil
+}
+
+// PurgeFIR physically deletes an expunged FIR from world state together with every record
+// kept under it: its private details, accused name index entries, case diary, arrests,
+// final reports, station transfer records, and its evidence with their custody chains.
+// Warrants and bail orders are court records and are kept, but their link to the FIR, the
+// party and the arrest is removed. The sealed FIR access log is kept on purpose as the
+// audit trail of who read the FIR while it was sealed, and after a purge only the
+// judiciary can read it; the record of who submitted each write, including the purge
+// itself, is kept for GetFIRHistory. Each FIR number in a Zero FIR transfer trail is a
+// separate record and is sealed and purged on its own. Earlier versions of every record
+// remain in the blocks of the ledger, which no transaction can rewrite; private details
+// are purged from the peers' private data history as well.
+//
+// Only members of the police organisation may write the private data collection, so a
+// FIR with private details must be purged by its SHO; the judiciary can purge a FIR
+// without them. The key-level endorsement policy set by SealFIR means the transaction is
+// only valid when the judiciary's peers endorse it too.
+func (s *SmartContract) PurgeFIR(ctx contractapi.TransactionContextInterface, firID string) error {
+	fir, err := readFIR(ctx, firID)
+	if err != nil {
+		return err
+	}
+	if err := requireJudiciaryOrSHO(ctx, fir.Station); err != nil {
+		return err
+	}
+	if fir.Seal == nil || fir.Seal.Disposition != DispositionExpunged {
+		return fmt.Errorf("the FIR %s can only be purged after it has been sealed as %s", firID, DispositionExpunged)
+	}
+
+	// a transferred FIR's private details belong to the case under its new number
+	if fir.TransferredTo == "" && hasPrivateDetails(fir) {
+		if err := onlyPolice(ctx); err != nil {
+			return fmt.Errorf("the FIR %s has private details, which only its SHO can purge: %v", firID, err)
+		}
+		if err := purgeFIRPrivateDetails(ctx, fir); err != nil {
+			return err
+		}
+	}
+	for _, prefix := range []string{diaryKeyPrefix, arrestKeyPrefix, finalReportKeyPrefix, stationTransferKeyPrefix} {
+		if err := deleteByPartialKey(ctx, prefix, firID); err != nil {
+			return fmt.Errorf("failed to delete %s records for FIR %s: %v", prefix, firID, err)
+		}
+	}
+
+	if err := deleteAccusedIndex(ctx, fir); err != nil {
+		return fmt.Errorf("failed to delete accused index entries for FIR %s: %v", firID, err)
+	}
+
+	evidence, err := evidenceForFIR(ctx, firID)
+	if err != nil {
+		return err
+	}
+	for _, item := range evidence {
+		if err := deleteByPartialKey(ctx, custodyKeyPrefix, item.EvidenceID); err != nil {
+			return fmt.Errorf("failed to delete custody chain of evidence %s: %v", item.EvidenceID, err)
+		}
+		key, err := evidenceKey(ctx, item.EvidenceID)
+		if err != nil {
+			return err
+		}
+		if err := ctx.GetStub().DelState(key); err != nil {
+			return fmt.Errorf("failed to delete evidence %s: %v", item.EvidenceID, err)
+		}
+	}
+
+	warrantIDs, err := firLinks(ctx, firID, linkWarrant)
+	if err != nil {
+		return err
+	}
+	for _, warrantID := range warrantIDs {
+		warrant, err := readWarrant(ctx, warrantID)
+		if err != nil {
+			return err
+		}
+		warrant.FIRID = ""
+		warrant.PartyNo = 0
+		if warrant.Execution != nil {
+			warrant.Execution.ArrestNo = 0
+		}
+		if err := putWarrant(ctx, warrant); err != nil {
+			return err
+		}
+	}
+	bailIDs, err := firLinks(ctx, firID, linkBail)
+	if err != nil {
+		return err
+	}
+	for _, bailID := range bailIDs {
+		bail, err := readBailOrder(ctx, bailID)
+		if err != nil {
+			return err
+		}
+		bail.FIRID = ""
+		bail.PartyNo = 0
+		bail.ArrestNo = 0
+		if err := putBailOrder(ctx, bail); err != nil {
+			return err
+		}
+	}
+	if err := deleteByPartialKey(ctx, firLinkKeyPrefix, firID); err != nil {
+		return fmt.Errorf("failed to delete link index entries for FIR %s: %v", firID, err)
+	}
+
+	mspid, submitter, err := getSubmitter(ctx)
+	if err != nil {
+		return err
+	}
+	if err := putFIRAudit(ctx, firID, mspid, submitter); err != nil {
+		return err
+	}
+	return ctx.GetStub().DelState(firID)
+}
+
+// deleteByPartialKey deletes every record whose composite key starts with prefix and id
+func deleteByPartialKey(ctx contractapi.TransactionContextInterface, prefix, id string) error {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(prefix, []string{id})
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	var keys []string
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		keys = append(keys, queryResponse.Key)
+	}
+	for _, key := range keys {
+		if err := ctx.GetStub().DelState(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
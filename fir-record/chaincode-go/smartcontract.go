@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
 )
@@ -12,15 +13,79 @@ type SmartContract struct {
 	contractapi.Contract
 }
 
+// firDocType marks FIR documents in world state so rich queries can select them
+const firDocType = "fir"
+
 // FIR describes a First Information Report
 type FIR struct {
-	Accused     string `json:"Accused"`
+	DocType     string `json:"DocType"`
 	CrimeType   string `json:"CrimeType"`
 	Description string `json:"Description"`
 	FiledBy     string `json:"FiledBy"`
 	FIRID       string `json:"FIRID"`
+	Station     string `json:"Station"`
 	Status      string `json:"Status"`
 	Timestamp   string `json:"Timestamp"`
+
+	// Parties lists the accused; victims, complainants and witnesses are kept in the
+	// private details and only listed in PrivateParties. PartyCount numbers parties across
+	// both; see parties.go
+	Parties        []Party           `json:"Parties,omitempty" metadata:",optional"`
+	PrivateParties []PrivatePartyRef `json:"PrivateParties,omitempty" metadata:",optional"`
+	PartyCount     int               `json:"PartyCount"`
+
+	// Accused is the single accused of FIRs filed before structured parties. It is
+	// converted to a party whenever such a FIR is read.
+	Accused string `json:"Accused,omitempty" metadata:",optional"`
+
+	// AccusedNames are the normalised names and aliases the FIR is indexed under for
+	// QueryFIRsByAccused. putFIR keeps them and the index in step with Parties.
+	AccusedNames []string `json:"AccusedNames,omitempty" metadata:",optional"`
+
+	LastModifiedBy  string `json:"LastModifiedBy"`
+	LastModifiedMSP string `json:"LastModifiedMSP"`
+
+	// PrivateDetailsHash is the SHA-256 of the FIR's entry in the private data collection.
+	// PrivateDetailsID is the FIR number that entry is kept under when the case has since
+	// been registered under another number; see zerofir.go
+	PrivateDetailsHash string `json:"PrivateDetailsHash"`
+	PrivateDetailsID   string `json:"PrivateDetailsID,omitempty" metadata:",optional"`
+
+	// InvestigatingOfficer is the officer ID of the assigned IO; see investigation.go
+	InvestigatingOfficer string         `json:"InvestigatingOfficer"`
+	IOAssignments        []IOAssignment `json:"IOAssignments,omitempty" metadata:",optional"`
+
+	// Juvenile is set once any party is recorded as under 18. Such FIRs are withheld from
+	// the public view, and the flag is never cleared; see publicview.go
+	Juvenile bool `json:"Juvenile"`
+
+	// DiaryEntryCount is the number of case diary entries; see diary.go
+	DiaryEntryCount int `json:"DiaryEntryCount"`
+
+	// ArrestCount is the number of arrests made under the FIR; see arrest.go
+	ArrestCount int `json:"ArrestCount"`
+
+	// Final reports submitted to court; see finalreport.go
+	ReportCount   int `json:"ReportCount"`
+	OpenReportSeq int `json:"OpenReportSeq"`
+
+	// Station transfers of a Zero FIR; see zerofir.go. TransferredFrom and TransferredTo
+	// link the FIR numbers the case was registered under at each station.
+	TransferCount   int    `json:"TransferCount"`
+	OpenTransferSeq int    `json:"OpenTransferSeq"`
+	TransferredFrom string `json:"TransferredFrom,omitempty" metadata:",optional"`
+	TransferredTo   string `json:"TransferredTo,omitempty" metadata:",optional"`
+
+	// Court orders attached by the judiciary; see courtorder.go
+	CourtOrders []CourtOrder `json:"CourtOrders,omitempty" metadata:",optional"`
+	Stayed      bool         `json:"Stayed"`
+
+	// Seal is set once a FIR has been withdrawn or expunged; see seal.go
+	Seal *SealRecord `json:"Seal,omitempty" metadata:",optional"`
+
+	// PrivateDetails and Redacted are filled in by ReadFIR and never written to world state
+	PrivateDetails *FIRPrivateDetails `json:"PrivateDetails,omitempty" metadata:",optional"`
+	Redacted       bool               `json:"Redacted,omitempty" metadata:",optional"`
 }
 
 // getMSPID returns the client's MSP ID
@@ -28,6 +93,54 @@ func getMSPID(ctx contractapi.TransactionContextInterface) (string, error) {
 	return ctx.GetClientIdentity().GetMSPID()
 }
 
+// getSubmitter returns the MSP ID and certificate common name of the submitting client
+func getSubmitter(ctx contractapi.TransactionContextInterface) (string, string, error) {
+	mspid, err := getMSPID(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to get MSP ID: %v", err)
+	}
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return "", "", fmt.Errorf("unable to get client certificate: %v", err)
+	}
+	return mspid, cert.Subject.CommonName, nil
+}
+
+// getTxTimestamp returns the transaction timestamp as an RFC 3339 string
+func getTxTimestamp(ctx contractapi.TransactionContextInterface) (string, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return "", fmt.Errorf("unable to get transaction timestamp: %v", err)
+	}
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC().Format(time.RFC3339), nil
+}
+
+// putFIR stamps the submitting identity on a FIR and writes it to world state
+func putFIR(ctx contractapi.TransactionContextInterface, fir *FIR) error {
+	mspid, submitter, err := getSubmitter(ctx)
+	if err != nil {
+		return err
+	}
+	fir.DocType = firDocType
+	fir.LastModifiedMSP = mspid
+	fir.LastModifiedBy = submitter
+	if err := indexAccusedNames(ctx, fir); err != nil {
+		return err
+	}
+	if err := putFIRAudit(ctx, fir.FIRID, mspid, submitter); err != nil {
+		return err
+	}
+
+	record := *fir
+	record.PrivateDetails = nil
+	record.Redacted = false
+	firJSON, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(fir.FIRID, firJSON)
+}
+
 // onlyPolice enforces access for Org1MSP (Police)
 func onlyPolice(ctx contractapi.TransactionContextInterface) error {
 	mspid, err := getMSPID(ctx)
@@ -40,59 +153,184 @@ func onlyPolice(ctx contractapi.TransactionContextInterface) error {
 	return nil
 }
 
-// InitLedger adds a base set of FIRs to the ledger
-func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
+// seededFIRsKeyPrefix keys the FIR numbers allocated by InitLedger, so that running it
+// again returns them instead of filing the seed FIRs a second time. It is a composite
+// key so that range scans over FIRs do not see it.
+const seededFIRsKeyPrefix = "seededfirs"
+
+// InitLedger adds a base set of FIRs to the ledger and returns their numbers. It only
+// seeds the ledger once; later calls return the numbers of the existing seed FIRs.
+func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	if err := requireRole(ctx, RoleSHO); err != nil {
+		return nil, err
+	}
+
+	seededKey, err := ctx.GetStub().CreateCompositeKey(seededFIRsKeyPrefix, nil)
+	if err != nil {
+		return nil, err
+	}
+	seededJSON, err := ctx.GetStub().GetState(seededKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if seededJSON != nil {
+		var firIDs []string
+		if err := json.Unmarshal(seededJSON, &firIDs); err != nil {
+			return nil, err
+		}
+		return firIDs, nil
+	}
+
 	firs := []FIR{
-		{FIRID: "FIR1", FiledBy: "OfficerA", Accused: "John Doe", CrimeType: "Theft", Description: "Stolen bike", Status: "Open", Timestamp: "2024-01-01T10:00:00Z"},
-		{FIRID: "FIR2", FiledBy: "OfficerB", Accused: "Jane Smith", CrimeType: "Assault", Description: "Physical altercation", Status: "Investigation", Timestamp: "2024-01-02T14:30:00Z"},
+		{Station: "MUM-CYB", FiledBy: "OfficerA", Accused: "John Doe", CrimeType: "Theft", Description: "Stolen bike", Status: StatusRegistered, Timestamp: "2024-01-01T10:00:00Z"},
+		{Station: "MUM-CYB", FiledBy: "OfficerB", Accused: "Jane Smith", CrimeType: "Assault", Description: "Physical altercation", Status: StatusUnderInvestigation, Timestamp: "2024-01-02T14:30:00Z"},
 	}
 
+	var firIDs []string
 	for _, fir := range firs {
-		firJSON, err := json.Marshal(fir)
+		convertLegacyAccused(&fir)
+		firID, err := allocateFIRNumber(ctx, fir.Station, fir.Timestamp[:4])
 		if err != nil {
-			return err
+			return nil, err
 		}
-		err = ctx.GetStub().PutState(fir.FIRID, firJSON)
+		fir.FIRID = firID
+		err = putFIR(ctx, &fir)
 		if err != nil {
-			return fmt.Errorf("failed to put to world state: %v", err)
+			return nil, fmt.Errorf("failed to put to world state: %v", err)
 		}
+		firIDs = append(firIDs, firID)
 	}
-	return nil
+
+	seededJSON, err = json.Marshal(firIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(seededKey, seededJSON); err != nil {
+		return nil, fmt.Errorf("failed to put to world state: %v", err)
+	}
+	return firIDs, nil
 }
 
-// FileFIR creates a new FIR entry in the ledger
-func (s *SmartContract) FileFIR(ctx contractapi.TransactionContextInterface, firID, filedBy, accused, crimeType, description, status, timestamp string) error {
-	if err := onlyPolice(ctx); err != nil {
-		return err
+// FileFIR creates a new FIR entry in the ledger and returns its number, allocated
+// sequentially per station and year (e.g. MUM-CYB/2025/00042). New FIRs always start in
+// the Registered state and belong to the station named in the caller's certificate. The
+// filing officer and time are taken from the submitting identity and the transaction
+// timestamp, and the officer must be active and posted to the station in policeman-record.
+// accusedJSON is a JSON array of the accused parties, which may be empty while they are
+// unidentified; victims, complainants and witnesses go in the private details, passed
+// in the transient map under "fir_private" with a random salt under "salt".
+func (s *SmartContract) FileFIR(ctx contractapi.TransactionContextInterface, accusedJSON, crimeType, description string) (string, error) {
+	if err := requireRole(ctx, policeRoles...); err != nil {
+		return "", err
+	}
+	station, err := getAttribute(ctx, attrStation)
+	if err != nil {
+		return "", err
 	}
 
+	filedBy, err := getOfficerID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := validateOfficer(ctx, filedBy, station); err != nil {
+		return "", err
+	}
+	timestamp, err := getTxTimestamp(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	firID, err := allocateFIRNumber(ctx, station, timestamp[:4])
+	if err != nil {
+		return "", err
+	}
 	exists, err := s.FIRExists(ctx, firID)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if exists {
-		return fmt.Errorf("the FIR %s already exists", firID)
+		return "", fmt.Errorf("the FIR %s already exists", firID)
 	}
 
 	fir := FIR{
 		FIRID:       firID,
+		Station:     station,
 		FiledBy:     filedBy,
-		Accused:     accused,
 		CrimeType:   crimeType,
 		Description: description,
-		Status:      status,
+		Status:      StatusRegistered,
 		Timestamp:   timestamp,
 	}
-	firJSON, err := json.Marshal(fir)
+
+	var accused []Party
+	if accusedJSON != "" {
+		err = json.Unmarshal([]byte(accusedJSON), &accused)
+		if err != nil {
+			return "", fmt.Errorf("accused must be a JSON array of parties: %v", err)
+		}
+	}
+	details, err := readTransientPrivateDetails(ctx)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return ctx.GetStub().PutState(firID, firJSON)
+	for _, party := range accused {
+		if party.Role == "" {
+			party.Role = PartyAccused
+		}
+		if party.Role != PartyAccused {
+			return "", fmt.Errorf("only accused may be passed as FIR arguments; pass a %s in the private details", party.Role)
+		}
+		if _, err := addParty(&fir, party, filedBy, timestamp); err != nil {
+			return "", err
+		}
+	}
+	if details != nil {
+		salt, err := readTransientSalt(ctx)
+		if err != nil {
+			return "", err
+		}
+		private := details.Parties
+		details.Parties = nil
+		for _, party := range private {
+			if party.Role == PartyAccused {
+				return "", fmt.Errorf("accused must be passed as FIR arguments, not in the private details")
+			}
+			added, err := addParty(&fir, party, filedBy, timestamp)
+			if err != nil {
+				return "", err
+			}
+			details.Parties = append(details.Parties, *added)
+		}
+		if err := putFIRPrivateDetails(ctx, &fir, details, salt); err != nil {
+			return "", err
+		}
+	}
+
+	if err := putFIR(ctx, &fir); err != nil {
+		return "", err
+	}
+	if err := emitEvent(ctx, EventFIRFiled, FIREvent{FIRID: fir.FIRID, Station: fir.Station, Status: fir.Status}); err != nil {
+		return "", err
+	}
+	return firID, nil
 }
 
-// ReadFIR retrieves a FIR record by ID
+// ReadFIR retrieves a FIR record by ID. Private details are included when the
+// caller is authorised to read them and redacted otherwise.
 func (s *SmartContract) ReadFIR(ctx contractapi.TransactionContextInterface, firID string) (*FIR, error) {
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return nil, err
+	}
+	if err := mergeFIRPrivateDetails(ctx, fir); err != nil {
+		return nil, err
+	}
+	return fir, nil
+}
+
+// readFIR retrieves the public part of a FIR from world state
+func readFIR(ctx contractapi.TransactionContextInterface, firID string) (*FIR, error) {
 	firJSON, err := ctx.GetStub().GetState(firID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read from world state: %v", err)
@@ -106,42 +344,63 @@ func (s *SmartContract) ReadFIR(ctx contractapi.TransactionContextInterface, fir
 	if err != nil {
 		return nil, err
 	}
+	convertLegacyAccused(&fir)
 	return &fir, nil
 }
 
-// UpdateFIR allows updates to an existing FIR record
-func (s *SmartContract) UpdateFIR(ctx contractapi.TransactionContextInterface, firID, status string) error {
-	if err := onlyPolice(ctx); err != nil {
-		return err
+// readActiveFIR retrieves the public part of a FIR, refusing FIRs that have been sealed
+func readActiveFIR(ctx contractapi.TransactionContextInterface, firID string) (*FIR, error) {
+	fir, err := readFIR(ctx, firID)
+	if err != nil {
+		return nil, err
 	}
+	if fir.Seal != nil {
+		return nil, sealedError(fir)
+	}
+	return fir, nil
+}
 
-	fir, err := s.ReadFIR(ctx, firID)
+// UpdateFIR moves an existing FIR to a new lifecycle status. Only the SHO or an IO of
+// the owning station may update a FIR, only the SHO may close it, and once an IO is
+// assigned only that IO or the SHO may update it. The updating officer must be active
+// and posted to the station in policeman-record. The status cannot be changed while a
+// final report is pending before the court, and a FIR can only be closed after its
+// chargesheet once the court has accepted it.
+func (s *SmartContract) UpdateFIR(ctx contractapi.TransactionContextInterface, firID, status string) error {
+	fir, err := readActiveFIR(ctx, firID)
 	if err != nil {
 		return err
 	}
 
-	fir.Status = status
-	updatedJSON, err := json.Marshal(fir)
-	if err != nil {
+	allowedRoles := []string{RoleSHO, RoleIO}
+	if status == StatusClosed {
+		allowedRoles = []string{RoleSHO}
+	}
+	if _, err := requireInvestigator(ctx, fir, allowedRoles...); err != nil {
 		return err
 	}
-	return ctx.GetStub().PutState(firID, updatedJSON)
-}
+	if fir.Stayed {
+		return fmt.Errorf("the FIR %s is stayed by court order and cannot be updated", firID)
+	}
 
-// DeleteFIR removes a FIR record from the ledger
-func (s *SmartContract) DeleteFIR(ctx contractapi.TransactionContextInterface, firID string) error {
-	if err := onlyPolice(ctx); err != nil {
+	if fir.OpenReportSeq != 0 {
+		return fmt.Errorf("the FIR %s has a final report pending before the court; its status follows the court's decision", firID)
+	}
+	if err := validateTransition(firID, fir.Status, status); err != nil {
 		return err
 	}
+	if status == StatusClosed {
+		if err := requireAcceptedChargesheet(ctx, fir); err != nil {
+			return err
+		}
+	}
 
-	exists, err := s.FIRExists(ctx, firID)
-	if err != nil {
+	previousStatus := fir.Status
+	fir.Status = status
+	if err := putFIR(ctx, fir); err != nil {
 		return err
 	}
-	if !exists {
-		return fmt.Errorf("the FIR %s does not exist", firID)
-	}
-	return ctx.GetStub().DelState(firID)
+	return emitEvent(ctx, EventFIRStatusChanged, FIREvent{FIRID: firID, Station: fir.Station, Status: status, PreviousStatus: previousStatus})
 }
 
 // FIRExists checks if a FIR exists in world state
@@ -153,7 +412,7 @@ func (s *SmartContract) FIRExists(ctx contractapi.TransactionContextInterface, f
 	return firJSON != nil, nil
 }
 
-// GetAllFIRs returns all FIRs from the ledger
+// GetAllFIRs returns all FIRs from the ledger, excluding sealed FIRs
 func (s *SmartContract) GetAllFIRs(ctx contractapi.TransactionContextInterface) ([]*FIR, error) {
 	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
 	if err != nil {
@@ -173,6 +432,10 @@ func (s *SmartContract) GetAllFIRs(ctx contractapi.TransactionContextInterface)
 		if err != nil {
 			return nil, err
 		}
+		if fir.Seal != nil {
+			continue
+		}
+		convertLegacyAccused(&fir)
 		firs = append(firs, &fir)
 	}
 	return firs, nil
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Warrant types the judiciary can issue
+const (
+	WarrantArrest  = "Arrest"
+	WarrantSearch  = "Search"
+	WarrantSummons = "Summons"
+)
+
+// Outcomes the police can record when executing a warrant
+const (
+	WarrantArrested           = "Arrested"
+	WarrantServed             = "Served"
+	WarrantReturnedUnexecuted = "ReturnedUnexecuted"
+)
+
+const (
+	warrantDocType   = "warrant"
+	warrantKeyPrefix = "warrant"
+)
+
+// WarrantExecution is the police record of how a warrant was executed
+type WarrantExecution struct {
+	Outcome     string `json:"Outcome"`
+	ExecutedBy  string `json:"ExecutedBy"`
+	ExecutedMSP string `json:"ExecutedMSP"`
+	ExecutedAt  string `json:"ExecutedAt"`
+	Remarks     string `json:"Remarks"`
+	TxID        string `json:"TxID"`
+
+	// ArrestNo links an Arrested outcome to the arrest recorded under the FIR; see arrest.go
+	ArrestNo int `json:"ArrestNo,omitempty" metadata:",optional"`
+}
+
+// Warrant is a warrant issued by the judiciary against a FIR or a person. The judiciary
+// writes the issue and recall fields and the police write only Execution.
+type Warrant struct {
+	DocType     string `json:"DocType"`
+	WarrantID   string `json:"WarrantID"`
+	WarrantType string `json:"WarrantType"`
+
+	// FIRID and PartyNo are set when the warrant is issued under a FIR; Station is the
+	// FIR's station, or the station directed to execute a warrant against a person alone
+	FIRID      string `json:"FIRID,omitempty" metadata:",optional"`
+	PartyNo    int    `json:"PartyNo,omitempty" metadata:",optional"`
+	Station    string `json:"Station"`
+	PersonName string `json:"PersonName"`
+	PersonID   string `json:"PersonID,omitempty" metadata:",optional"`
+
+	Court      string `json:"Court"`
+	ValidUntil string `json:"ValidUntil,omitempty" metadata:",optional"`
+	Remarks    string `json:"Remarks,omitempty" metadata:",optional"`
+	IssuedBy   string `json:"IssuedBy"`
+	IssuedMSP  string `json:"IssuedMSP"`
+	IssuedAt   string `json:"IssuedAt"`
+	TxID       string `json:"TxID"`
+
+	Recalled     bool   `json:"Recalled"`
+	RecalledAt   string `json:"RecalledAt,omitempty" metadata:",optional"`
+	RecallReason string `json:"RecallReason,omitempty" metadata:",optional"`
+
+	Execution *WarrantExecution `json:"Execution,omitempty" metadata:",optional"`
+}
+
+// warrantKey returns the world state key for a warrant
+func warrantKey(ctx contractapi.TransactionContextInterface, warrantID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(warrantKeyPrefix, []string{warrantID})
+}
+
+func readWarrant(ctx contractapi.TransactionContextInterface, warrantID string) (*Warrant, error) {
+	key, err := warrantKey(ctx, warrantID)
+	if err != nil {
+		return nil, err
+	}
+	warrantJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if warrantJSON == nil {
+		return nil, fmt.Errorf("the warrant %s does not exist", warrantID)
+	}
+
+	var warrant Warrant
+	err = json.Unmarshal(warrantJSON, &warrant)
+	if err != nil {
+		return nil, err
+	}
+	return &warrant, nil
+}
+
+func putWarrant(ctx contractapi.TransactionContextInterface, warrant *Warrant) error {
+	key, err := warrantKey(ctx, warrant.WarrantID)
+	if err != nil {
+		return err
+	}
+	warrant.DocType = warrantDocType
+
+	warrantJSON, err := json.Marshal(warrant)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, warrantJSON)
+}
+
+// resolveCourtSubject fills in the station and person a court order is directed at. Under
+// a FIR the station is the FIR's and the person may be given as an accused party number;
+// otherwise the station and person name must be given.
+func resolveCourtSubject(ctx contractapi.TransactionContextInterface, firID string, partyNo int, station, personName *string) error {
+	if firID == "" {
+		if partyNo != 0 {
+			return fmt.Errorf("a party number can only be given with a FIR")
+		}
+		if *station == "" || *personName == "" {
+			return fmt.Errorf("a station and person name are required when no FIR is given")
+		}
+		return nil
+	}
+
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return err
+	}
+	if *station != "" && *station != fir.Station {
+		return fmt.Errorf("the FIR %s belongs to station %s, not %s", firID, fir.Station, *station)
+	}
+	*station = fir.Station
+
+	if partyNo != 0 {
+		for _, party := range fir.Parties {
+			if party.PartyNo == partyNo && party.Role == PartyAccused {
+				if *personName == "" {
+					*personName = party.Name
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("the FIR %s has no accused with party number %d", firID, partyNo)
+	}
+	if *personName == "" {
+		return fmt.Errorf("a person name or accused party number is required")
+	}
+	return nil
+}
+
+// IssueWarrant records a warrant issued by the judiciary. warrantJSON carries the
+// WarrantID, WarrantType, Court and either a FIRID (with an optional accused PartyNo) or a
+// Station and PersonName, plus optional PersonID, ValidUntil and Remarks. Only the judiciary
+// may issue warrants.
+func (s *SmartContract) IssueWarrant(ctx contractapi.TransactionContextInterface, warrantJSON string) error {
+	if err := onlyJudiciary(ctx); err != nil {
+		return err
+	}
+
+	var input Warrant
+	err := json.Unmarshal([]byte(warrantJSON), &input)
+	if err != nil {
+		return fmt.Errorf("failed to parse warrant: %v", err)
+	}
+	switch input.WarrantType {
+	case WarrantArrest, WarrantSearch, WarrantSummons:
+	default:
+		return fmt.Errorf("invalid warrant type %q", input.WarrantType)
+	}
+	if input.WarrantID == "" || input.Court == "" {
+		return fmt.Errorf("a warrant requires a warrant ID and the issuing court")
+	}
+	if err := resolveCourtSubject(ctx, input.FIRID, input.PartyNo, &input.Station, &input.PersonName); err != nil {
+		return err
+	}
+
+	key, err := warrantKey(ctx, input.WarrantID)
+	if err != nil {
+		return err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("the warrant %s already exists", input.WarrantID)
+	}
+
+	mspid, submitter, err := getSubmitter(ctx)
+	if err != nil {
+		return err
+	}
+	issuedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	warrant := Warrant{
+		WarrantID:   input.WarrantID,
+		WarrantType: input.WarrantType,
+		FIRID:       input.FIRID,
+		PartyNo:     input.PartyNo,
+		Station:     input.Station,
+		PersonName:  input.PersonName,
+		PersonID:    input.PersonID,
+		Court:       input.Court,
+		ValidUntil:  input.ValidUntil,
+		Remarks:     input.Remarks,
+		IssuedBy:    submitter,
+		IssuedMSP:   mspid,
+		IssuedAt:    issuedAt,
+		TxID:        ctx.GetStub().GetTxID(),
+	}
+	if err := putWarrant(ctx, &warrant); err != nil {
+		return err
+	}
+	if warrant.FIRID == "" {
+		return nil
+	}
+	return putFIRLink(ctx, warrant.FIRID, linkWarrant, warrant.WarrantID)
+}
+
+// RecallWarrant cancels a warrant that has not been executed. Only the judiciary may
+// recall a warrant, and a reason is required.
+func (s *SmartContract) RecallWarrant(ctx contractapi.TransactionContextInterface, warrantID, reason string) error {
+	if err := onlyJudiciary(ctx); err != nil {
+		return err
+	}
+	if reason == "" {
+		return fmt.Errorf("a reason is required to recall warrant %s", warrantID)
+	}
+	warrant, err := readWarrant(ctx, warrantID)
+	if err != nil {
+		return err
+	}
+	if warrant.Recalled {
+		return fmt.Errorf("the warrant %s is already recalled", warrantID)
+	}
+	if warrant.Execution != nil {
+		return fmt.Errorf("the warrant %s has already been executed (%s)", warrantID, warrant.Execution.Outcome)
+	}
+	recalledAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	warrant.Recalled = true
+	warrant.RecalledAt = recalledAt
+	warrant.RecallReason = reason
+	return putWarrant(ctx, warrant)
+}
+
+// ExecuteWarrant records how the police executed a warrant: Arrested, Served or
+// ReturnedUnexecuted. The executing officer and time are taken from the submitting
+// identity and the transaction timestamp, and the officer must be active and posted to the
+// warrant's station. arrestNo links an arrest warrant under a FIR to the arrest recorded
+// for it, or is 0.
+func (s *SmartContract) ExecuteWarrant(ctx contractapi.TransactionContextInterface, warrantID, outcome, remarks string, arrestNo int) error {
+	warrant, err := readWarrant(ctx, warrantID)
+	if err != nil {
+		return err
+	}
+	if err := requireStationRole(ctx, warrant.Station, policeRoles...); err != nil {
+		return err
+	}
+	officerID, err := getOfficerID(ctx)
+	if err != nil {
+		return err
+	}
+	if err := validateOfficer(ctx, officerID, warrant.Station); err != nil {
+		return err
+	}
+
+	if warrant.Recalled {
+		return fmt.Errorf("the warrant %s has been recalled", warrantID)
+	}
+	if warrant.Execution != nil {
+		return fmt.Errorf("the warrant %s has already been executed (%s)", warrantID, warrant.Execution.Outcome)
+	}
+	switch outcome {
+	case WarrantArrested:
+		if warrant.WarrantType != WarrantArrest {
+			return fmt.Errorf("a %s warrant cannot be executed by arrest", warrant.WarrantType)
+		}
+	case WarrantServed, WarrantReturnedUnexecuted:
+	default:
+		return fmt.Errorf("invalid warrant outcome %q", outcome)
+	}
+	if outcome == WarrantReturnedUnexecuted && remarks == "" {
+		return fmt.Errorf("a reason is required to return warrant %s unexecuted", warrantID)
+	}
+	if arrestNo != 0 {
+		if outcome != WarrantArrested || warrant.FIRID == "" {
+			return fmt.Errorf("an arrest can only be linked to an arrest warrant issued under a FIR")
+		}
+		arrest, err := readArrest(ctx, warrant.FIRID, arrestNo)
+		if err != nil {
+			return err
+		}
+		if warrant.PartyNo != 0 && arrest.PartyNo != warrant.PartyNo {
+			return fmt.Errorf("arrest %d under FIR %s is of party %d, not party %d named in the warrant", arrestNo, warrant.FIRID, arrest.PartyNo, warrant.PartyNo)
+		}
+	}
+
+	mspid, _, err := getSubmitter(ctx)
+	if err != nil {
+		return err
+	}
+	executedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	warrant.Execution = &WarrantExecution{
+		Outcome:     outcome,
+		ExecutedBy:  officerID,
+		ExecutedMSP: mspid,
+		ExecutedAt:  executedAt,
+		Remarks:     remarks,
+		TxID:        ctx.GetStub().GetTxID(),
+		ArrestNo:    arrestNo,
+	}
+	return putWarrant(ctx, warrant)
+}
+
+// ReadWarrant retrieves a warrant by ID
+func (s *SmartContract) ReadWarrant(ctx contractapi.TransactionContextInterface, warrantID string) (*Warrant, error) {
+	return readWarrant(ctx, warrantID)
+}
+
+// QueryOutstandingWarrantsByStation returns the warrants directed at a station that have
+// been neither executed nor recalled. Requires CouchDB.
+func (s *SmartContract) QueryOutstandingWarrantsByStation(ctx contractapi.TransactionContextInterface, station string) ([]*Warrant, error) {
+	return queryOutstandingWarrants(ctx, map[string]interface{}{"Station": station})
+}
+
+// QueryOutstandingWarrantsByPerson returns the warrants against a person, matched by
+// person ID or name, that have been neither executed nor recalled. Requires CouchDB.
+func (s *SmartContract) QueryOutstandingWarrantsByPerson(ctx contractapi.TransactionContextInterface, person string) ([]*Warrant, error) {
+	return queryOutstandingWarrants(ctx, map[string]interface{}{
+		"$or": []interface{}{
+			map[string]interface{}{"PersonID": person},
+			map[string]interface{}{"PersonName": person},
+		},
+	})
+}
+
+// queryOutstandingWarrants runs a CouchDB selector restricted to outstanding warrants
+func queryOutstandingWarrants(ctx contractapi.TransactionContextInterface, selector map[string]interface{}) ([]*Warrant, error) {
+	selector["Recalled"] = false
+	selector["Execution"] = map[string]interface{}{"$exists": false}
+	return queryWarrants(ctx, selector)
+}
+
+// queryWarrants runs a CouchDB selector restricted to warrants
+func queryWarrants(ctx contractapi.TransactionContextInterface, selector map[string]interface{}) ([]*Warrant, error) {
+	selector["DocType"] = warrantDocType
+	queryJSON, err := json.Marshal(map[string]interface{}{"selector": selector})
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run query: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var warrants []*Warrant
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var warrant Warrant
+		err = json.Unmarshal(queryResponse.Value, &warrant)
+		if err != nil {
+			return nil, err
+		}
+		warrants = append(warrants, &warrant)
+	}
+	return warrants, nil
+}
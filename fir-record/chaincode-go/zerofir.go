@@ -0,0 +1,416 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Station transfer states
+const (
+	StationTransferRequested = "Requested"
+	StationTransferAccepted  = "Accepted"
+	StationTransferRejected  = "Rejected"
+)
+
+const stationTransferKeyPrefix = "firtransfer"
+
+// StationTransfer records a request to move a FIR, typically a Zero FIR registered
+// without jurisdiction, to another police station and the destination's decision
+type StationTransfer struct {
+	FIRID       string `json:"FIRID"`
+	Seq         int    `json:"Seq"`
+	Status      string `json:"Status"`
+	FromStation string `json:"FromStation"`
+	ToStation   string `json:"ToStation"`
+	Reason      string `json:"Reason"`
+	RequestedBy string `json:"RequestedBy"`
+	RequestedAt string `json:"RequestedAt"`
+	RequestTxID string `json:"RequestTxID"`
+
+	DecidedBy       string `json:"DecidedBy,omitempty" metadata:",optional"`
+	DecidedAt       string `json:"DecidedAt,omitempty" metadata:",optional"`
+	DecisionTxID    string `json:"DecisionTxID,omitempty" metadata:",optional"`
+	RejectionReason string `json:"RejectionReason,omitempty" metadata:",optional"`
+
+	// NewFIRID is the number the destination station registered the FIR under
+	NewFIRID string `json:"NewFIRID,omitempty" metadata:",optional"`
+}
+
+// stationTransferKey returns the world state key for a station transfer of a FIR
+func stationTransferKey(ctx contractapi.TransactionContextInterface, firID string, seq int) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(stationTransferKeyPrefix, []string{firID, fmt.Sprintf("%06d", seq)})
+}
+
+func readStationTransfer(ctx contractapi.TransactionContextInterface, firID string, seq int) (*StationTransfer, error) {
+	key, err := stationTransferKey(ctx, firID, seq)
+	if err != nil {
+		return nil, err
+	}
+	transferJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if transferJSON == nil {
+		return nil, fmt.Errorf("station transfer %d of FIR %s does not exist", seq, firID)
+	}
+
+	var transfer StationTransfer
+	err = json.Unmarshal(transferJSON, &transfer)
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func putStationTransfer(ctx contractapi.TransactionContextInterface, transfer *StationTransfer) error {
+	key, err := stationTransferKey(ctx, transfer.FIRID, transfer.Seq)
+	if err != nil {
+		return err
+	}
+	transferJSON, err := json.Marshal(transfer)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, transferJSON)
+}
+
+// readOpenStationTransfer returns a FIR and its pending station transfer, checking that
+// the caller is an active SHO of the destination station
+func readOpenStationTransfer(ctx contractapi.TransactionContextInterface, firID string) (*FIR, *StationTransfer, string, error) {
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if fir.OpenTransferSeq == 0 {
+		return nil, nil, "", fmt.Errorf("the FIR %s has no pending station transfer", firID)
+	}
+	transfer, err := readStationTransfer(ctx, firID, fir.OpenTransferSeq)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if err := requireStationRole(ctx, transfer.ToStation, RoleSHO); err != nil {
+		return nil, nil, "", err
+	}
+	officerID, err := getOfficerID(ctx)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if err := validateOfficer(ctx, officerID, transfer.ToStation); err != nil {
+		return nil, nil, "", err
+	}
+	return fir, transfer, officerID, nil
+}
+
+// RequestTransfer asks another station to take over a FIR registered at a station without
+// jurisdiction. Only the SHO of the FIR's current station may request it, and a FIR can
+// have only one pending transfer.
+func (s *SmartContract) RequestTransfer(ctx contractapi.TransactionContextInterface, firID, toStation, reason string) error {
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return err
+	}
+	officerID, err := requireInvestigator(ctx, fir, RoleSHO)
+	if err != nil {
+		return err
+	}
+	if toStation == "" || toStation == fir.Station {
+		return fmt.Errorf("the FIR %s must be transferred to a station other than %s", firID, fir.Station)
+	}
+	if reason == "" {
+		return fmt.Errorf("a reason is required to transfer FIR %s", firID)
+	}
+	switch normalizeStatus(fir.Status) {
+	case StatusClosed, StatusTransferred:
+		return fmt.Errorf("the FIR %s is %s and cannot be transferred", firID, fir.Status)
+	}
+	if fir.OpenTransferSeq != 0 {
+		return fmt.Errorf("the FIR %s already has a pending station transfer", firID)
+	}
+	if fir.OpenReportSeq != 0 {
+		return fmt.Errorf("the FIR %s has a final report pending before the court and cannot be transferred", firID)
+	}
+
+	requestedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	fir.TransferCount++
+	transfer := StationTransfer{
+		FIRID:       firID,
+		Seq:         fir.TransferCount,
+		Status:      StationTransferRequested,
+		FromStation: fir.Station,
+		ToStation:   toStation,
+		Reason:      reason,
+		RequestedBy: officerID,
+		RequestedAt: requestedAt,
+		RequestTxID: ctx.GetStub().GetTxID(),
+	}
+	if err := putStationTransfer(ctx, &transfer); err != nil {
+		return err
+	}
+
+	fir.OpenTransferSeq = transfer.Seq
+	return putFIR(ctx, fir)
+}
+
+// AcceptTransfer registers a FIR at the destination station of its pending transfer and
+// returns the new FIR number. The new FIR carries the record of the original, links back to
+// it through TransferredFrom and has no Investigating Officer; the original is marked
+// Transferred. The case diary, arrests and final reports move to the new number with their
+// numbering unchanged, and evidence, warrants and bail orders are re-linked to it, so the
+// case continues under one FIR number. Only the SHO of the destination station may accept.
+func (s *SmartContract) AcceptTransfer(ctx contractapi.TransactionContextInterface, firID string) (string, error) {
+	fir, transfer, officerID, err := readOpenStationTransfer(ctx, firID)
+	if err != nil {
+		return "", err
+	}
+	decidedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	newFIRID, err := allocateFIRNumber(ctx, transfer.ToStation, decidedAt[:4])
+	if err != nil {
+		return "", err
+	}
+	exists, err := s.FIRExists(ctx, newFIRID)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", fmt.Errorf("the FIR %s already exists", newFIRID)
+	}
+
+	newFIR := *fir
+	newFIR.FIRID = newFIRID
+	newFIR.Station = transfer.ToStation
+	newFIR.TransferredFrom = firID
+	newFIR.InvestigatingOfficer = ""
+	newFIR.IOAssignments = nil
+	newFIR.OpenReportSeq = 0
+	newFIR.TransferCount = 0
+	newFIR.OpenTransferSeq = 0
+	// the private details stay under the number they were filed under, so the transfer
+	// never reads the collection
+	newFIR.PrivateDetailsID = privateDetailsID(fir)
+	newFIR.PrivateParties = append([]PrivatePartyRef(nil), fir.PrivateParties...)
+
+	if err := putFIR(ctx, &newFIR); err != nil {
+		return "", err
+	}
+	if err := relinkFIRRecords(ctx, firID, newFIRID); err != nil {
+		return "", err
+	}
+
+	transfer.Status = StationTransferAccepted
+	transfer.DecidedBy = officerID
+	transfer.DecidedAt = decidedAt
+	transfer.DecisionTxID = ctx.GetStub().GetTxID()
+	transfer.NewFIRID = newFIRID
+	if err := putStationTransfer(ctx, transfer); err != nil {
+		return "", err
+	}
+
+	previousStatus := fir.Status
+	fir.Status = StatusTransferred
+	fir.TransferredTo = newFIRID
+	fir.OpenTransferSeq = 0
+	fir.DiaryEntryCount = 0
+	fir.ArrestCount = 0
+	fir.ReportCount = 0
+	if err := putFIR(ctx, fir); err != nil {
+		return "", err
+	}
+
+	if err := emitEvent(ctx, EventFIRTransferred, FIREvent{FIRID: newFIRID, Station: newFIR.Station, Status: newFIR.Status, PreviousStatus: previousStatus, TransferredFrom: firID}); err != nil {
+		return "", err
+	}
+	return newFIRID, nil
+}
+
+// relinkFIRRecords moves the records kept under a FIR number to the number it was
+// registered under at another station
+func relinkFIRRecords(ctx contractapi.TransactionContextInterface, firID, newFIRID string) error {
+	if err := moveFIRRecords(ctx, diaryKeyPrefix, firID, newFIRID, func(entry *CaseDiaryEntry) {
+		entry.FIRID = newFIRID
+	}); err != nil {
+		return err
+	}
+	if err := moveFIRRecords(ctx, arrestKeyPrefix, firID, newFIRID, func(arrest *Arrest) {
+		arrest.FIRID = newFIRID
+	}); err != nil {
+		return err
+	}
+	if err := moveFIRRecords(ctx, finalReportKeyPrefix, firID, newFIRID, func(report *FinalReport) {
+		report.FIRID = newFIRID
+	}); err != nil {
+		return err
+	}
+
+	evidence, err := evidenceForFIR(ctx, firID)
+	if err != nil {
+		return err
+	}
+	for _, item := range evidence {
+		item.FIRID = newFIRID
+		if err := putEvidence(ctx, item); err != nil {
+			return err
+		}
+	}
+
+	warrantIDs, err := firLinks(ctx, firID, linkWarrant)
+	if err != nil {
+		return err
+	}
+	for _, warrantID := range warrantIDs {
+		warrant, err := readWarrant(ctx, warrantID)
+		if err != nil {
+			return err
+		}
+		warrant.FIRID = newFIRID
+		if err := putWarrant(ctx, warrant); err != nil {
+			return err
+		}
+	}
+
+	bailIDs, err := firLinks(ctx, firID, linkBail)
+	if err != nil {
+		return err
+	}
+	for _, bailID := range bailIDs {
+		bail, err := readBailOrder(ctx, bailID)
+		if err != nil {
+			return err
+		}
+		bail.FIRID = newFIRID
+		if err := putBailOrder(ctx, bail); err != nil {
+			return err
+		}
+	}
+	return moveFIRLinks(ctx, firID, newFIRID)
+}
+
+// moveFIRRecords re-keys every record stored under prefix [firID, seq] to prefix
+// [newFIRID, seq], letting relink update the FIR number held in the record itself
+func moveFIRRecords[T any](ctx contractapi.TransactionContextInterface, prefix, firID, newFIRID string, relink func(*T)) error {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(prefix, []string{firID})
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return err
+		}
+
+		var record T
+		if err := json.Unmarshal(queryResponse.Value, &record); err != nil {
+			return err
+		}
+		relink(&record)
+		recordJSON, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		newKey, err := ctx.GetStub().CreateCompositeKey(prefix, append([]string{newFIRID}, attributes[1:]...))
+		if err != nil {
+			return err
+		}
+		if err := ctx.GetStub().PutState(newKey, recordJSON); err != nil {
+			return fmt.Errorf("failed to move %s record of FIR %s: %v", prefix, firID, err)
+		}
+		if err := ctx.GetStub().DelState(queryResponse.Key); err != nil {
+			return fmt.Errorf("failed to move %s record of FIR %s: %v", prefix, firID, err)
+		}
+	}
+	return nil
+}
+
+// RejectTransfer declines the pending transfer of a FIR, which stays with its current
+// station. Only the SHO of the destination station may reject, and a reason is required.
+func (s *SmartContract) RejectTransfer(ctx contractapi.TransactionContextInterface, firID, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("a reason is required to reject the transfer of FIR %s", firID)
+	}
+	fir, transfer, officerID, err := readOpenStationTransfer(ctx, firID)
+	if err != nil {
+		return err
+	}
+	decidedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	transfer.Status = StationTransferRejected
+	transfer.DecidedBy = officerID
+	transfer.DecidedAt = decidedAt
+	transfer.DecisionTxID = ctx.GetStub().GetTxID()
+	transfer.RejectionReason = reason
+	if err := putStationTransfer(ctx, transfer); err != nil {
+		return err
+	}
+
+	fir.OpenTransferSeq = 0
+	return putFIR(ctx, fir)
+}
+
+// GetTransferTrail returns every station transfer in the history of a FIR, oldest first.
+// Any FIR number in the chain, original or renumbered, returns the same trail.
+func (s *SmartContract) GetTransferTrail(ctx contractapi.TransactionContextInterface, firID string) ([]*StationTransfer, error) {
+	fir, err := readActiveFIR(ctx, firID)
+	if err != nil {
+		return nil, err
+	}
+
+	// walk back to the FIR as first registered
+	for fir.TransferredFrom != "" {
+		fir, err = readFIR(ctx, fir.TransferredFrom)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var trail []*StationTransfer
+	for {
+		resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(stationTransferKeyPrefix, []string{fir.FIRID})
+		if err != nil {
+			return nil, err
+		}
+		for resultsIterator.HasNext() {
+			queryResponse, err := resultsIterator.Next()
+			if err != nil {
+				resultsIterator.Close()
+				return nil, err
+			}
+
+			var transfer StationTransfer
+			err = json.Unmarshal(queryResponse.Value, &transfer)
+			if err != nil {
+				resultsIterator.Close()
+				return nil, err
+			}
+			trail = append(trail, &transfer)
+		}
+		resultsIterator.Close()
+
+		if fir.TransferredTo == "" {
+			return trail, nil
+		}
+		fir, err = readFIR(ctx, fir.TransferredTo)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
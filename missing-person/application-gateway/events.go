@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// stationEvent holds the payload fields of PersonMatchCandidates and PersonMatchConfirmed
+// events that name the stations concerned. Truncated is set on candidates found when not
+// every report could be scored.
+type stationEvent struct {
+	ReportID            string   `json:"ReportID"`
+	Truncated           bool     `json:"Truncated"`
+	AlertStations       []string `json:"AlertStations"`
+	MissingStation      string   `json:"MissingStation"`
+	UnidentifiedStation string   `json:"UnidentifiedStation"`
+}
+
+// concerns reports whether an event names station
+func (e *stationEvent) concerns(station string) bool {
+	if e.MissingStation == station || e.UnidentifiedStation == station {
+		return true
+	}
+	for _, s := range e.AlertStations {
+		if s == station {
+			return true
+		}
+	}
+	return false
+}
+
+// listen prints the match events that concern a station as they are committed until
+// interrupted. Pass a block number to replay events from that block onwards.
+func listen(network *client.Network, chaincodeName, station string, args []string) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var options []client.ChaincodeEventsOption
+	if len(args) > 0 {
+		startBlock, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			panic(fmt.Errorf("invalid start block %q: %w", args[0], err))
+		}
+		options = append(options, client.WithStartBlock(startBlock))
+	}
+
+	events, err := network.ChaincodeEvents(ctx, chaincodeName, options...)
+	if err != nil {
+		panic(fmt.Errorf("failed to start chaincode event listening: %w", err))
+	}
+
+	fmt.Printf("\n*** Listening for match alerts for station %s, press Ctrl+C to stop\n", station)
+	for event := range events {
+		var payload stationEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			fmt.Fprintf(os.Stderr, "*** Failed to parse event %s from tx %s: %v\n", event.EventName, event.TransactionID, err)
+			continue
+		}
+		if !payload.concerns(station) {
+			continue
+		}
+		fmt.Printf("\n<-- Chaincode event received: %s (block %d, tx %s)\n%s\n", event.EventName, event.BlockNumber, event.TransactionID, formatJSON(event.Payload))
+		if payload.Truncated {
+			fmt.Printf("*** Not every report was scored; run \"matches %s\" for the full list\n", payload.ReportID)
+		}
+	}
+}
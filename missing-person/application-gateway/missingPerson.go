@@ -0,0 +1,261 @@
+/*
+Copyright 2021 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/hash"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// User1 carries the role=SHO and station=MUM-CYB certificate attributes that registry
+// transactions require. They are only issued when the network is started with Fabric CA (-ca).
+const (
+	mspID        = "Org1MSP"
+	cryptoPath   = "../../police-network/organizations/peerOrganizations/org1.example.com"
+	certPath     = cryptoPath + "/users/User1@org1.example.com/msp/signcerts"
+	keyPath      = cryptoPath + "/users/User1@org1.example.com/msp/keystore"
+	tlsCertPath  = cryptoPath + "/peers/peer0.org1.example.com/tls/ca.crt"
+	peerEndpoint = "dns:///localhost:7051"
+	gatewayPeer  = "peer0.org1.example.com"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	clientConnection := newGrpcConnection()
+	defer clientConnection.Close()
+
+	id := newIdentity()
+	sign := newSign()
+
+	gw, err := client.Connect(
+		id,
+		client.WithSign(sign),
+		client.WithHash(hash.SHA256),
+		client.WithClientConnection(clientConnection),
+		client.WithEvaluateTimeout(5*time.Second),
+		client.WithEndorseTimeout(15*time.Second),
+		client.WithSubmitTimeout(5*time.Second),
+		client.WithCommitStatusTimeout(1*time.Minute),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer gw.Close()
+
+	chaincodeName := "missingperson"
+	if ccname := os.Getenv("CHAINCODE_NAME"); ccname != "" {
+		chaincodeName = ccname
+	}
+
+	channelName := "mychannel"
+	if cname := os.Getenv("CHANNEL_NAME"); cname != "" {
+		channelName = cname
+	}
+
+	network := gw.GetNetwork(channelName)
+	runCommand(network, chaincodeName, os.Args[1:])
+}
+
+// runCommand runs a single command given on the command line
+func runCommand(network *client.Network, chaincodeName string, args []string) {
+	contract := network.GetContract(chaincodeName)
+	switch {
+	case len(args) == 3 && args[0] == "register-missing":
+		registerReport(contract, "RegisterMissingPerson", args[1], map[string][]byte{
+			"complainant": []byte(args[2]),
+			"salt":        newSalt(),
+		})
+	case len(args) == 2 && args[0] == "register-unidentified":
+		registerReport(contract, "RegisterUnidentifiedPerson", args[1], nil)
+	case len(args) == 2 && args[0] == "show":
+		if strings.Contains(args[1], "/MP/") {
+			evaluateAndPrint(contract, "ReadMissingPerson", args[1])
+		} else {
+			evaluateAndPrint(contract, "ReadUnidentifiedPerson", args[1])
+		}
+	case len(args) == 2 && args[0] == "matches":
+		evaluateAndPrint(contract, "FindMatches", args[1])
+	case (len(args) == 3 || len(args) == 4) && args[0] == "confirm":
+		remarks := ""
+		if len(args) == 4 {
+			remarks = args[3]
+		}
+		submit(contract, "ConfirmMatch", fmt.Sprintf("identifies %s as %s", args[2], args[1]), args[1], args[2], remarks)
+	case len(args) == 3 && args[0] == "close":
+		submit(contract, "CloseReport", fmt.Sprintf("closes %s", args[1]), args[1], args[2])
+	case (len(args) == 2 || len(args) == 3) && args[0] == "listen":
+		listen(network, chaincodeName, args[1], args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: go run . <command>
+
+Commands:
+  register-missing <reportJSON> <complainantJSON>
+  register-unidentified <reportJSON>
+  show <reportID>
+  matches <reportID>
+  confirm <missingReportID> <unidentifiedReportID> [remarks]
+  close <reportID> <reason>
+  listen <station> [startBlock]
+
+Both kinds of report carry a Person description, for example
+{"Name":"Asha Patil","Person":{"AgeMin":14,"AgeMax":16,
+ "Gender":"Female","HeightMinCm":150,"HeightMaxCm":155,"IdentifyingMarks":["mole on left cheek"],
+ "LastSeenLocation":"Dadar railway station","LastSeenDate":"2025-03-02"}}
+A missing person report also names who reported it, which is sent as private data, for
+example {"Complainant":"Ramesh Patil","Contact":"+91 98200 00000"}.
+An unidentified person report replaces Name with a Condition
+(Living|Deceased) and gives where and when the person was found as the last-seen
+location and date.`)
+}
+
+func newGrpcConnection() *grpc.ClientConn {
+	certificatePEM, err := os.ReadFile(tlsCertPath)
+	if err != nil {
+		panic(fmt.Errorf("failed to read TLS certificate file: %w", err))
+	}
+
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		panic(err)
+	}
+
+	certPool := x509.NewCertPool()
+	certPool.AddCert(certificate)
+	transportCredentials := credentials.NewClientTLSFromCert(certPool, gatewayPeer)
+
+	connection, err := grpc.NewClient(peerEndpoint, grpc.WithTransportCredentials(transportCredentials))
+	if err != nil {
+		panic(fmt.Errorf("failed to create gRPC connection: %w", err))
+	}
+
+	return connection
+}
+
+func newIdentity() *identity.X509Identity {
+	certificatePEM, err := readFirstFile(certPath)
+	if err != nil {
+		panic(fmt.Errorf("failed to read certificate file: %w", err))
+	}
+
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		panic(err)
+	}
+
+	id, err := identity.NewX509Identity(mspID, certificate)
+	if err != nil {
+		panic(err)
+	}
+
+	return id
+}
+
+func newSign() identity.Sign {
+	privateKeyPEM, err := readFirstFile(keyPath)
+	if err != nil {
+		panic(fmt.Errorf("failed to read private key file: %w", err))
+	}
+
+	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
+	if err != nil {
+		panic(err)
+	}
+
+	sign, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		panic(err)
+	}
+
+	return sign
+}
+
+func readFirstFile(dirPath string) ([]byte, error) {
+	dir, err := os.Open(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fileNames, err := dir.Readdirnames(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(path.Join(dirPath, fileNames[0]))
+}
+
+// registerReport submits a new report, with any private data in the transient map, and
+// shows the candidate matches it was announced with
+func registerReport(contract *client.Contract, transactionName, reportJSON string, transient map[string][]byte) {
+	fmt.Printf("\n--> Submit Transaction: %s\n", transactionName)
+	reportID, err := contract.Submit(transactionName, client.WithArguments(reportJSON), client.WithTransient(transient))
+	if err != nil {
+		panic(fmt.Errorf("failed to submit %s: %w", transactionName, err))
+	}
+	fmt.Printf("*** Registered report %s\n", reportID)
+	evaluateAndPrint(contract, "FindMatches", string(reportID))
+}
+
+// newSalt returns a random salt for the complainant record. The chaincode stores it with the
+// record so its hash on the ledger cannot be matched against guessed names or numbers.
+func newSalt() []byte {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		panic(fmt.Errorf("failed to generate salt: %w", err))
+	}
+	return []byte(hex.EncodeToString(salt))
+}
+
+func submit(contract *client.Contract, transactionName, description string, args ...string) {
+	fmt.Printf("\n--> Submit Transaction: %s, %s\n", transactionName, description)
+	_, err := contract.SubmitTransaction(transactionName, args...)
+	if err != nil {
+		panic(fmt.Errorf("failed to submit %s: %w", transactionName, err))
+	}
+	fmt.Println("*** Transaction committed successfully")
+}
+
+func evaluateAndPrint(contract *client.Contract, transactionName string, args ...string) {
+	fmt.Printf("\n--> Evaluate Transaction: %s\n", transactionName)
+	result, err := contract.EvaluateTransaction(transactionName, args...)
+	if err != nil {
+		panic(fmt.Errorf("failed to evaluate %s: %w", transactionName, err))
+	}
+	fmt.Printf("*** Result: %s\n", formatJSON(result))
+}
+
+// Format JSON data
+func formatJSON(data []byte) string {
+	var prettyJSON bytes.Buffer
+	if err := json.Indent(&prettyJSON, data, "", "  "); err != nil {
+		panic(fmt.Errorf("failed to parse JSON: %w", err))
+	}
+	return prettyJSON.String()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Missing person registry event names. Candidates are announced when a report is
+// registered; a confirmed match is announced to both stations.
+const (
+	EventMatchCandidates = "PersonMatchCandidates"
+	EventMatchConfirmed  = "PersonMatchConfirmed"
+)
+
+// MatchEvent is the payload of a PersonMatchCandidates event. AlertStations lists every
+// station holding the new report or a candidate, so each station's listener can pick out
+// the matches that concern it. Truncated is set when there were more possible matches
+// than registration scores; FindMatches then gives the full list.
+type MatchEvent struct {
+	ReportID      string            `json:"ReportID"`
+	Station       string            `json:"Station"`
+	AlertStations []string          `json:"AlertStations"`
+	Matches       []*MatchCandidate `json:"Matches"`
+	Truncated     bool              `json:"Truncated"`
+}
+
+// MatchConfirmedEvent is the payload of a PersonMatchConfirmed event
+type MatchConfirmedEvent struct {
+	MissingReportID      string `json:"MissingReportID"`
+	MissingStation       string `json:"MissingStation"`
+	UnidentifiedReportID string `json:"UnidentifiedReportID"`
+	UnidentifiedStation  string `json:"UnidentifiedStation"`
+}
+
+// emitMatchCandidates announces the candidate matches found for a newly registered
+// report. Nothing is emitted when there are none and every possible match was scored.
+func emitMatchCandidates(ctx contractapi.TransactionContextInterface, reportID, station string, matches []*MatchCandidate, truncated bool) error {
+	if len(matches) == 0 && !truncated {
+		return nil
+	}
+
+	event := MatchEvent{ReportID: reportID, Station: station, AlertStations: []string{station}, Matches: matches, Truncated: truncated}
+	seen := map[string]bool{station: true}
+	for _, match := range matches {
+		for _, other := range []string{match.MissingStation, match.UnidentifiedStation} {
+			if !seen[other] {
+				seen[other] = true
+				event.AlertStations = append(event.AlertStations, other)
+			}
+		}
+	}
+
+	payloadJSON, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().SetEvent(EventMatchCandidates, payloadJSON)
+}
+
+// emitMatchConfirmed announces a confirmed match to both stations
+func emitMatchConfirmed(ctx contractapi.TransactionContextInterface, missing *MissingPersonReport, unidentified *UnidentifiedPersonReport) error {
+	payloadJSON, err := json.Marshal(MatchConfirmedEvent{
+		MissingReportID:      missing.ReportID,
+		MissingStation:       missing.Station,
+		UnidentifiedReportID: unidentified.ReportID,
+		UnidentifiedStation:  unidentified.Station,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().SetEvent(EventMatchConfirmed, payloadJSON)
+}
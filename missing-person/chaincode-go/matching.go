@@ -0,0 +1,385 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/sudoice/pbc/chaincode-common"
+)
+
+const (
+	// minMatchScore is the lowest score reported as a candidate match, out of 100
+	minMatchScore = 40
+
+	// maxMatches bounds how many candidates are returned or announced for one report
+	maxMatches = 10
+
+	// registrationScanLimit bounds how many reports are scored when a report is registered,
+	// nearest in date first; FindMatches scores them all, matchPageSize at a time
+	registrationScanLimit = 200
+	matchPageSize         = 100
+
+	// Estimated ages and heights within these tolerances of each other still count as close
+	ageToleranceYears = 3
+	heightToleranceCm = 5
+)
+
+// Weights of each attribute in the match score. Gender and age ranges that cannot match,
+// and a person found before the missing person was last seen, rule a candidate out.
+const (
+	weightAge      = 20
+	weightHeight   = 20
+	weightMarks    = 40
+	weightLocation = 10
+	weightDate     = 10
+)
+
+// stopWords are ignored when comparing identifying marks and locations
+var stopWords = map[string]bool{
+	"the": true, "and": true, "with": true, "near": true, "from": true, "about": true,
+}
+
+// MatchCandidate is a possible match between a missing person report and an unidentified
+// person report, scored out of 100. Reasons lists the attributes that contributed.
+type MatchCandidate struct {
+	MissingReportID      string   `json:"MissingReportID"`
+	MissingStation       string   `json:"MissingStation"`
+	UnidentifiedReportID string   `json:"UnidentifiedReportID"`
+	UnidentifiedStation  string   `json:"UnidentifiedStation"`
+	Score                int      `json:"Score"`
+	Reasons              []string `json:"Reasons"`
+}
+
+// FindMatches ranks open reports of the other kind, at every station, as candidate
+// matches for a missing or unidentified person report. Unlike the matching done when a
+// report is registered, it pages through every candidate the hard exclusions leave. Only
+// police may search for matches. Requires CouchDB.
+func (s *SmartContract) FindMatches(ctx contractapi.TransactionContextInterface, reportID string) ([]*MatchCandidate, error) {
+	if err := common.OnlyPolice(ctx); err != nil {
+		return nil, err
+	}
+
+	if isMissingReportID(reportID) {
+		missing, err := readMissingPerson(ctx, reportID)
+		if err != nil {
+			return nil, err
+		}
+		matches, _, err := matchMissingPerson(ctx, missing, 0)
+		return matches, err
+	}
+	unidentified, err := readUnidentifiedPerson(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	matches, _, err := matchUnidentifiedPerson(ctx, unidentified, 0)
+	return matches, err
+}
+
+// matchMissingPerson scores a missing person report against the open unidentified person
+// reports and returns the best candidates, highest score first. scanLimit bounds how many
+// reports are scored, nearest in date first, and the result reports whether reports were
+// left unscored; zero pages through them all. Rich query
+// results are not re-checked when a transaction is validated, so a report registered
+// concurrently may be missed; it will find this one when it is matched in turn.
+func matchMissingPerson(ctx contractapi.TransactionContextInterface, missing *MissingPersonReport, scanLimit int) ([]*MatchCandidate, bool, error) {
+	candidates := []*MatchCandidate{}
+	query := candidateQuery(unidentifiedDocType, &missing.Person, "$gte", "asc")
+	truncated, err := scanReports(ctx, query, scanLimit, func(unidentified *UnidentifiedPersonReport) {
+		if score, reasons, ok := scoreMatch(&missing.Person, &unidentified.Person); ok {
+			candidates = addCandidate(candidates, &MatchCandidate{
+				MissingReportID:      missing.ReportID,
+				MissingStation:       missing.Station,
+				UnidentifiedReportID: unidentified.ReportID,
+				UnidentifiedStation:  unidentified.Station,
+				Score:                score,
+				Reasons:              reasons,
+			})
+		}
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return candidates, truncated, nil
+}
+
+// matchUnidentifiedPerson is matchMissingPerson for an unidentified person report
+func matchUnidentifiedPerson(ctx contractapi.TransactionContextInterface, unidentified *UnidentifiedPersonReport, scanLimit int) ([]*MatchCandidate, bool, error) {
+	candidates := []*MatchCandidate{}
+	query := candidateQuery(missingDocType, &unidentified.Person, "$lte", "desc")
+	truncated, err := scanReports(ctx, query, scanLimit, func(missing *MissingPersonReport) {
+		if score, reasons, ok := scoreMatch(&missing.Person, &unidentified.Person); ok {
+			candidates = addCandidate(candidates, &MatchCandidate{
+				MissingReportID:      missing.ReportID,
+				MissingStation:       missing.Station,
+				UnidentifiedReportID: unidentified.ReportID,
+				UnidentifiedStation:  unidentified.Station,
+				Score:                score,
+				Reasons:              reasons,
+			})
+		}
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return candidates, truncated, nil
+}
+
+// addCandidate inserts a candidate in score order, keeping at most maxMatches
+func addCandidate(candidates []*MatchCandidate, candidate *MatchCandidate) []*MatchCandidate {
+	i := sort.Search(len(candidates), func(i int) bool {
+		return candidates[i].Score < candidate.Score
+	})
+	if i == maxMatches {
+		return candidates
+	}
+	candidates = append(candidates, nil)
+	copy(candidates[i+1:], candidates[i:])
+	candidates[i] = candidate
+	if len(candidates) > maxMatches {
+		candidates = candidates[:maxMatches]
+	}
+	return candidates
+}
+
+// candidateQuery selects the open reports of one kind that the hard exclusions in
+// scoreMatch leave as possible matches for person: those of a compatible gender, last seen
+// on the right side of person's last-seen date. A missing person can only be found on or
+// after the day they were last seen, so dateOp is $gte when matching a missing person and
+// $lte when matching an unidentified one, and order sorts the nearest dates first.
+func candidateQuery(docType string, person *PersonDescription, dateOp, order string) map[string]interface{} {
+	selector := map[string]interface{}{
+		"DocType":             docType,
+		"Status":              StatusOpen,
+		"Person.LastSeenDate": map[string]interface{}{dateOp: person.LastSeenDate},
+	}
+	if person.Gender != GenderUnknown {
+		selector["Person.Gender"] = map[string]interface{}{"$in": []string{person.Gender, GenderUnknown}}
+	}
+	return map[string]interface{}{
+		"selector": selector,
+		"sort": []interface{}{
+			map[string]string{"DocType": order},
+			map[string]string{"Status": order},
+			map[string]string{"Person.LastSeenDate": order},
+		},
+		"use_index": []string{"_design/indexOpenReportsDoc", "indexOpenReports"},
+	}
+}
+
+// scanReports runs a report query and passes each report to visit. With a scanLimit it
+// stops after that many reports, because paginated queries are not allowed in the
+// transactions that register reports, and reports whether any were left; with none it
+// pages through every result.
+func scanReports[T any](ctx contractapi.TransactionContextInterface, query map[string]interface{}, scanLimit int, visit func(*T)) (bool, error) {
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return false, err
+	}
+
+	if scanLimit > 0 {
+		resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryJSON))
+		if err != nil {
+			return false, fmt.Errorf("failed to run query: %v", err)
+		}
+		defer resultsIterator.Close()
+
+		for scanned := 0; scanned < scanLimit && resultsIterator.HasNext(); scanned++ {
+			queryResponse, err := resultsIterator.Next()
+			if err != nil {
+				return false, err
+			}
+			var report T
+			if err := json.Unmarshal(queryResponse.Value, &report); err != nil {
+				return false, err
+			}
+			visit(&report)
+		}
+		return resultsIterator.HasNext(), nil
+	}
+
+	bookmark := ""
+	for {
+		resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(string(queryJSON), matchPageSize, bookmark)
+		if err != nil {
+			return false, fmt.Errorf("failed to run query: %v", err)
+		}
+		for resultsIterator.HasNext() {
+			queryResponse, err := resultsIterator.Next()
+			if err != nil {
+				resultsIterator.Close()
+				return false, err
+			}
+			var report T
+			if err := json.Unmarshal(queryResponse.Value, &report); err != nil {
+				resultsIterator.Close()
+				return false, err
+			}
+			visit(&report)
+		}
+		resultsIterator.Close()
+
+		if metadata.FetchedRecordsCount < matchPageSize || metadata.Bookmark == "" || metadata.Bookmark == bookmark {
+			return false, nil
+		}
+		bookmark = metadata.Bookmark
+	}
+}
+
+// scoreMatch scores an unidentified person against a missing person. It returns false
+// when the two cannot be the same person or the score is below minMatchScore.
+func scoreMatch(missing, found *PersonDescription) (int, []string, bool) {
+	if missing.Gender != GenderUnknown && found.Gender != GenderUnknown && missing.Gender != found.Gender {
+		return 0, nil, false
+	}
+
+	var score int
+	var reasons []string
+
+	// the missing person has aged by the time they are found
+	ageMin, ageMax := missing.AgeMin, missing.AgeMax
+	lastSeen, err1 := time.Parse(dateLayout, missing.LastSeenDate)
+	foundOn, err2 := time.Parse(dateLayout, found.LastSeenDate)
+	if err1 == nil && err2 == nil {
+		if foundOn.Before(lastSeen) {
+			return 0, nil, false
+		}
+		if years := yearsBetween(lastSeen, foundOn); years > 0 && ageMax != 0 {
+			ageMax += years
+			if ageMin != 0 {
+				ageMin += years
+			}
+		}
+		switch days := foundOn.Sub(lastSeen).Hours() / 24; {
+		case days <= 30:
+			score += weightDate
+			reasons = append(reasons, fmt.Sprintf("found %.0f days after last seen", days))
+		case days <= 365:
+			score += weightDate / 2
+			reasons = append(reasons, fmt.Sprintf("found %.0f days after last seen", days))
+		}
+	}
+
+	if ageMax != 0 && found.AgeMax != 0 {
+		switch gap := rangeGap(ageMin, ageMax, found.AgeMin, found.AgeMax); {
+		case gap == 0:
+			score += weightAge
+			reasons = append(reasons, "age ranges overlap")
+		case gap <= ageToleranceYears:
+			score += weightAge / 2
+			reasons = append(reasons, fmt.Sprintf("age ranges within %d years", gap))
+		default:
+			return 0, nil, false
+		}
+	}
+
+	if missing.HeightMaxCm != 0 && found.HeightMaxCm != 0 {
+		switch gap := rangeGap(missing.HeightMinCm, missing.HeightMaxCm, found.HeightMinCm, found.HeightMaxCm); {
+		case gap == 0:
+			score += weightHeight
+			reasons = append(reasons, "height ranges overlap")
+		case gap <= heightToleranceCm:
+			score += weightHeight / 2
+			reasons = append(reasons, fmt.Sprintf("height ranges within %d cm", gap))
+		}
+	}
+
+	if len(missing.IdentifyingMarks) > 0 && len(found.IdentifyingMarks) > 0 {
+		var matched []string
+		for _, mark := range missing.IdentifyingMarks {
+			for _, other := range found.IdentifyingMarks {
+				if similarity(words(mark), words(other)) >= 0.5 {
+					matched = append(matched, mark)
+					break
+				}
+			}
+		}
+		if len(matched) > 0 {
+			score += weightMarks * len(matched) / len(missing.IdentifyingMarks)
+			reasons = append(reasons, "identifying marks: "+strings.Join(matched, "; "))
+		}
+	}
+
+	if shared := sharedWords(words(missing.LastSeenLocation), words(found.LastSeenLocation)); len(shared) > 0 {
+		score += weightLocation
+		reasons = append(reasons, "locations share "+strings.Join(shared, ", "))
+	}
+
+	if score < minMatchScore {
+		return 0, nil, false
+	}
+	return score, reasons, true
+}
+
+// yearsBetween returns the number of whole years from one date to a later one
+func yearsBetween(from, to time.Time) int {
+	years := to.Year() - from.Year()
+	if to.Month() < from.Month() || (to.Month() == from.Month() && to.Day() < from.Day()) {
+		years--
+	}
+	return years
+}
+
+// rangeGap returns how far apart two ranges are, or 0 when they overlap. A zero minimum
+// is treated as the maximum, so a single estimate can be given as the maximum alone.
+func rangeGap(min1, max1, min2, max2 int) int {
+	if min1 == 0 {
+		min1 = max1
+	}
+	if min2 == 0 {
+		min2 = max2
+	}
+	switch {
+	case max1 < min2:
+		return min2 - max1
+	case max2 < min1:
+		return min1 - max2
+	}
+	return 0
+}
+
+// words returns the distinct lower-case words of a description, ignoring stop words and
+// words shorter than three letters
+func words(text string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) < 3 || stopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		result = append(result, word)
+	}
+	return result
+}
+
+// sharedWords returns the words present in both a and b
+func sharedWords(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, word := range b {
+		inB[word] = true
+	}
+	var shared []string
+	for _, word := range a {
+		if inB[word] {
+			shared = append(shared, word)
+		}
+	}
+	return shared
+}
+
+// similarity returns the Jaccard similarity of two word sets
+func similarity(a, b []string) float64 {
+	union := len(a) + len(b)
+	if union == 0 {
+		return 0
+	}
+	shared := len(sharedWords(a, b))
+	return float64(shared) / float64(union-shared)
+}
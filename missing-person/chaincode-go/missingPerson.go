@@ -0,0 +1,22 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"log"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+func main() {
+	missingPersonChaincode, err := contractapi.NewChaincode(&SmartContract{})
+	if err != nil {
+		log.Panicf("Error creating missing persons registry chaincode: %v", err)
+	}
+
+	if err := missingPersonChaincode.Start(); err != nil {
+		log.Panicf("Error starting missing persons registry chaincode: %v", err)
+	}
+}
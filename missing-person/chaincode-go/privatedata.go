@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/sudoice/pbc/chaincode-common"
+)
+
+const (
+	// complainantCollection holds who reported a person missing and how to reach them; see
+	// collections_config.json. Only Org1 may read or write it.
+	complainantCollection = "missingPersonComplainants"
+
+	// complainantTransientKey is the transient map key RegisterMissingPerson reads the
+	// complainant from
+	complainantTransientKey = "complainant"
+
+	// saltTransientKey is the transient map key of the random salt stored with the
+	// complainant, so the hash on the ledger cannot be matched against guessed names or
+	// numbers. The client supplies it because every endorser must store the same bytes.
+	saltTransientKey = "salt"
+	minSaltLength    = 32
+)
+
+// ComplainantDetails identifies who reported a person missing. It is stored only on Org1
+// peers; the report keeps its hash.
+type ComplainantDetails struct {
+	ReportID    string `json:"ReportID"`
+	Complainant string `json:"Complainant"`
+	Contact     string `json:"Contact,omitempty" metadata:",optional"`
+	Salt        string `json:"Salt"`
+}
+
+// readTransientComplainant reads the complainant and salt passed to RegisterMissingPerson
+// in the transient map
+func readTransientComplainant(ctx contractapi.TransactionContextInterface) (*ComplainantDetails, error) {
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transient map: %v", err)
+	}
+	complainantJSON, ok := transientMap[complainantTransientKey]
+	if !ok {
+		return nil, fmt.Errorf("the complainant must be passed in the transient map under %q", complainantTransientKey)
+	}
+
+	var details ComplainantDetails
+	err = json.Unmarshal(complainantJSON, &details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s transient data: %v", complainantTransientKey, err)
+	}
+	if details.Complainant == "" {
+		return nil, fmt.Errorf("a missing person report requires the complainant")
+	}
+	details.Salt = string(transientMap[saltTransientKey])
+	if len(details.Salt) < minSaltLength {
+		return nil, fmt.Errorf("a random salt of at least %d characters must be passed in the transient map under %q", minSaltLength, saltTransientKey)
+	}
+	return &details, nil
+}
+
+// putComplainant stores the complainant of a report and records the hex SHA-256 of the
+// stored bytes on the report
+func putComplainant(ctx contractapi.TransactionContextInterface, report *MissingPersonReport, details *ComplainantDetails) error {
+	details.ReportID = report.ReportID
+
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutPrivateData(complainantCollection, report.ReportID, detailsJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put the complainant of report %s: %v", report.ReportID, err)
+	}
+
+	hash := sha256.Sum256(detailsJSON)
+	report.ComplainantHash = hex.EncodeToString(hash[:])
+	return nil
+}
+
+// mergeComplainant attaches the complainant to a report for the SHO or an IO of its
+// station, checking it against the hash on the ledger, and marks it redacted for everyone
+// else
+func mergeComplainant(ctx contractapi.TransactionContextInterface, report *MissingPersonReport) error {
+	if report.ComplainantHash == "" {
+		return nil
+	}
+	if common.RequireStationRole(ctx, report.Station, common.RoleSHO, common.RoleIO) != nil {
+		report.Redacted = true
+		return nil
+	}
+
+	detailsJSON, err := ctx.GetStub().GetPrivateData(complainantCollection, report.ReportID)
+	if err != nil {
+		return fmt.Errorf("failed to read the complainant of report %s: %v", report.ReportID, err)
+	}
+	if detailsJSON == nil {
+		// this peer does not hold the collection
+		report.Redacted = true
+		return nil
+	}
+
+	hash := sha256.Sum256(detailsJSON)
+	if hex.EncodeToString(hash[:]) != report.ComplainantHash {
+		return fmt.Errorf("the complainant of report %s does not match the hash on the ledger", report.ReportID)
+	}
+	var details ComplainantDetails
+	if err := json.Unmarshal(detailsJSON, &details); err != nil {
+		return err
+	}
+	report.ComplainantDetails = &details
+	return nil
+}
@@ -0,0 +1,541 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/sudoice/pbc/chaincode-common"
+)
+
+// SmartContract provides functions for the missing persons registry
+type SmartContract struct {
+	contractapi.Contract
+}
+
+// Report states
+const (
+	StatusOpen    = "Open"
+	StatusMatched = "Matched"
+	StatusClosed  = "Closed"
+)
+
+// Genders recorded in a person description. Unknown matches any gender.
+const (
+	GenderMale        = "Male"
+	GenderFemale      = "Female"
+	GenderTransgender = "Transgender"
+	GenderUnknown     = "Unknown"
+)
+
+// Condition of an unidentified person when found
+const (
+	ConditionLiving   = "Living"
+	ConditionDeceased = "Deceased"
+)
+
+const (
+	missingDocType      = "missing"
+	unidentifiedDocType = "unidentified"
+	reportSeqKeyPrefix  = "reportseq"
+
+	// Report IDs carry the station and the kind of report, e.g. MUM-CYB/MP/00007
+	missingIDInfix      = "MP"
+	unidentifiedIDInfix = "UP"
+
+	// dateLayout is the format of last-seen and found dates
+	dateLayout = "2006-01-02"
+)
+
+// PersonDescription holds the descriptive attributes compared when matching missing and
+// unidentified persons. Ages and heights are ranges because they are often estimates;
+// a zero maximum means the attribute is not known. For an unidentified person the
+// last-seen location and date are where and when the person was found.
+type PersonDescription struct {
+	AgeMin           int      `json:"AgeMin"`
+	AgeMax           int      `json:"AgeMax"`
+	Gender           string   `json:"Gender"`
+	HeightMinCm      int      `json:"HeightMinCm"`
+	HeightMaxCm      int      `json:"HeightMaxCm"`
+	IdentifyingMarks []string `json:"IdentifyingMarks,omitempty" metadata:",optional"`
+	LastSeenLocation string   `json:"LastSeenLocation"`
+	LastSeenDate     string   `json:"LastSeenDate"`
+}
+
+// MissingPersonReport is a report of a missing person registered at a station
+type MissingPersonReport struct {
+	DocType  string `json:"DocType"`
+	ReportID string `json:"ReportID"`
+	Station  string `json:"Station"`
+	FIRID    string `json:"FIRID,omitempty" metadata:",optional"`
+	Status   string `json:"Status"`
+
+	Name   string            `json:"Name"`
+	Person PersonDescription `json:"Person"`
+
+	// ComplainantHash is the SHA-256 of the complainant record kept in the private
+	// collection; see privatedata.go
+	ComplainantHash string `json:"ComplainantHash"`
+
+	ReportedBy string `json:"ReportedBy"`
+	ReportedAt string `json:"ReportedAt"`
+	TxID       string `json:"TxID"`
+
+	Resolution *Resolution `json:"Resolution,omitempty" metadata:",optional"`
+
+	// ComplainantDetails and Redacted are filled in by ReadMissingPerson and never written
+	// to world state
+	ComplainantDetails *ComplainantDetails `json:"ComplainantDetails,omitempty" metadata:",optional"`
+	Redacted           bool                `json:"Redacted,omitempty" metadata:",optional"`
+}
+
+// UnidentifiedPersonReport is a report of a living or deceased person found at a station
+// whose identity is not known
+type UnidentifiedPersonReport struct {
+	DocType  string `json:"DocType"`
+	ReportID string `json:"ReportID"`
+	Station  string `json:"Station"`
+	FIRID    string `json:"FIRID,omitempty" metadata:",optional"`
+	Status   string `json:"Status"`
+
+	Condition string            `json:"Condition"`
+	Person    PersonDescription `json:"Person"`
+	Remarks   string            `json:"Remarks,omitempty" metadata:",optional"`
+
+	ReportedBy string `json:"ReportedBy"`
+	ReportedAt string `json:"ReportedAt"`
+	TxID       string `json:"TxID"`
+
+	Resolution *Resolution `json:"Resolution,omitempty" metadata:",optional"`
+}
+
+// Resolution records how a report left the Open state. MatchedWith names the report on
+// the other side of a confirmed match and is empty when the report was closed without one.
+type Resolution struct {
+	MatchedWith string `json:"MatchedWith,omitempty" metadata:",optional"`
+	Remarks     string `json:"Remarks"`
+	ResolvedBy  string `json:"ResolvedBy"`
+	ResolvedAt  string `json:"ResolvedAt"`
+	TxID        string `json:"TxID"`
+}
+
+// getTxTimestamp returns the transaction timestamp as an RFC 3339 string
+func getTxTimestamp(ctx contractapi.TransactionContextInterface) (string, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return "", fmt.Errorf("unable to get transaction timestamp: %v", err)
+	}
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC().Format(time.RFC3339), nil
+}
+
+// validatePerson checks a person description and normalises its gender
+func validatePerson(person *PersonDescription) error {
+	switch person.Gender {
+	case GenderMale, GenderFemale, GenderTransgender, GenderUnknown:
+	case "":
+		person.Gender = GenderUnknown
+	default:
+		return fmt.Errorf("invalid gender %q", person.Gender)
+	}
+	if person.AgeMin < 0 || (person.AgeMax != 0 && person.AgeMax < person.AgeMin) {
+		return fmt.Errorf("invalid age range %d-%d", person.AgeMin, person.AgeMax)
+	}
+	if person.HeightMinCm < 0 || (person.HeightMaxCm != 0 && person.HeightMaxCm < person.HeightMinCm) {
+		return fmt.Errorf("invalid height range %d-%d cm", person.HeightMinCm, person.HeightMaxCm)
+	}
+	if person.LastSeenLocation == "" {
+		return fmt.Errorf("the last-seen location is required")
+	}
+	if _, err := time.Parse(dateLayout, person.LastSeenDate); err != nil {
+		return fmt.Errorf("the last-seen date must be given as YYYY-MM-DD: %v", err)
+	}
+	return nil
+}
+
+// requireReportingOfficer enforces that the caller is police of a station and returns
+// that station and the caller's officer ID
+func requireReportingOfficer(ctx contractapi.TransactionContextInterface) (string, string, error) {
+	if err := common.OnlyPolice(ctx); err != nil {
+		return "", "", err
+	}
+	station, err := common.GetStation(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if err := common.RequireStationRole(ctx, station, common.PoliceRoles...); err != nil {
+		return "", "", err
+	}
+	officerID, err := common.GetOfficerID(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	return station, officerID, nil
+}
+
+// checkLinkedFIR verifies an optional FIR against fir-record and that it belongs to station.
+// A FIR transferred to another station is refused in favour of its new number.
+func checkLinkedFIR(ctx contractapi.TransactionContextInterface, firID, station string) error {
+	if firID == "" {
+		return nil
+	}
+	fir, err := common.ReadFIR(ctx, firID)
+	if err != nil {
+		return err
+	}
+	if fir.TransferredTo != "" {
+		return fmt.Errorf("the FIR %s has been transferred and is now %s", firID, fir.TransferredTo)
+	}
+	if fir.Station != station {
+		return fmt.Errorf("FIR %s belongs to station %s, not %s", firID, fir.Station, station)
+	}
+	return nil
+}
+
+// allocateReportID returns the next report ID of a kind at a station. Concurrent reports
+// of the same kind at a station conflict on the counter and the later one must be
+// resubmitted.
+func allocateReportID(ctx contractapi.TransactionContextInterface, station, infix string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(reportSeqKeyPrefix, []string{station, infix})
+	if err != nil {
+		return "", err
+	}
+	seqJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	var last int
+	if seqJSON != nil {
+		err = json.Unmarshal(seqJSON, &last)
+		if err != nil {
+			return "", err
+		}
+	}
+	last++
+
+	seqJSON, err = json.Marshal(last)
+	if err != nil {
+		return "", err
+	}
+	if err := ctx.GetStub().PutState(key, seqJSON); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s/%05d", station, infix, last), nil
+}
+
+// readReport reads a report of either kind into report
+func readReport(ctx contractapi.TransactionContextInterface, docType, reportID string, report interface{}) error {
+	key, err := ctx.GetStub().CreateCompositeKey(docType, []string{reportID})
+	if err != nil {
+		return err
+	}
+	reportJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if reportJSON == nil {
+		return fmt.Errorf("the %s person report %s does not exist", docType, reportID)
+	}
+	return json.Unmarshal(reportJSON, report)
+}
+
+func putReport(ctx contractapi.TransactionContextInterface, docType, reportID string, report interface{}) error {
+	key, err := ctx.GetStub().CreateCompositeKey(docType, []string{reportID})
+	if err != nil {
+		return err
+	}
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, reportJSON)
+}
+
+func readMissingPerson(ctx contractapi.TransactionContextInterface, reportID string) (*MissingPersonReport, error) {
+	var report MissingPersonReport
+	if err := readReport(ctx, missingDocType, reportID, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func readUnidentifiedPerson(ctx contractapi.TransactionContextInterface, reportID string) (*UnidentifiedPersonReport, error) {
+	var report UnidentifiedPersonReport
+	if err := readReport(ctx, unidentifiedDocType, reportID, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// isMissingReportID reports whether reportID names a missing person report
+func isMissingReportID(reportID string) bool {
+	return strings.Contains(reportID, "/"+missingIDInfix+"/")
+}
+
+// RegisterMissingPerson registers a missing person report at the caller's station and
+// returns its report ID. reportJSON carries the Name, Person description and optional FIRID.
+// The Complainant and optional Contact are passed in the transient map under "complainant",
+// with a random salt under "salt", and are kept in the Org1 private collection. The new report is matched against the open unidentified
+// person reports found nearest in date at every station, and any candidates are announced
+// in a PersonMatchCandidates event, which also says when not every report was scored; see
+// matching.go.
+func (s *SmartContract) RegisterMissingPerson(ctx contractapi.TransactionContextInterface, reportJSON string) (string, error) {
+	station, officerID, err := requireReportingOfficer(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var input MissingPersonReport
+	err = json.Unmarshal([]byte(reportJSON), &input)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse missing person report: %v", err)
+	}
+	if input.Name == "" {
+		return "", fmt.Errorf("a missing person report requires the missing person's name")
+	}
+	complainant, err := readTransientComplainant(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := validatePerson(&input.Person); err != nil {
+		return "", err
+	}
+	if err := checkLinkedFIR(ctx, input.FIRID, station); err != nil {
+		return "", err
+	}
+
+	reportedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return "", err
+	}
+	reportID, err := allocateReportID(ctx, station, missingIDInfix)
+	if err != nil {
+		return "", err
+	}
+
+	report := MissingPersonReport{
+		DocType:    missingDocType,
+		ReportID:   reportID,
+		Station:    station,
+		FIRID:      input.FIRID,
+		Status:     StatusOpen,
+		Name:       input.Name,
+		Person:     input.Person,
+		ReportedBy: officerID,
+		ReportedAt: reportedAt,
+		TxID:       ctx.GetStub().GetTxID(),
+	}
+	if err := putComplainant(ctx, &report, complainant); err != nil {
+		return "", err
+	}
+	if err := putReport(ctx, missingDocType, reportID, &report); err != nil {
+		return "", err
+	}
+
+	// The report was written in this transaction and cannot be read back, so it is matched as is
+	matches, truncated, err := matchMissingPerson(ctx, &report, registrationScanLimit)
+	if err != nil {
+		return "", err
+	}
+	if err := emitMatchCandidates(ctx, reportID, station, matches, truncated); err != nil {
+		return "", err
+	}
+	return reportID, nil
+}
+
+// RegisterUnidentifiedPerson registers a report of an unidentified living or deceased
+// person found by the caller's station and returns its report ID. reportJSON carries the
+// Condition, Person description and optional Remarks and FIRID. Like a missing person
+// report, it is matched against open reports of the other kind at every station.
+func (s *SmartContract) RegisterUnidentifiedPerson(ctx contractapi.TransactionContextInterface, reportJSON string) (string, error) {
+	station, officerID, err := requireReportingOfficer(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var input UnidentifiedPersonReport
+	err = json.Unmarshal([]byte(reportJSON), &input)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse unidentified person report: %v", err)
+	}
+	switch input.Condition {
+	case ConditionLiving, ConditionDeceased:
+	default:
+		return "", fmt.Errorf("invalid condition %q, must be %s or %s", input.Condition, ConditionLiving, ConditionDeceased)
+	}
+	if err := validatePerson(&input.Person); err != nil {
+		return "", err
+	}
+	if err := checkLinkedFIR(ctx, input.FIRID, station); err != nil {
+		return "", err
+	}
+
+	reportedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return "", err
+	}
+	reportID, err := allocateReportID(ctx, station, unidentifiedIDInfix)
+	if err != nil {
+		return "", err
+	}
+
+	report := UnidentifiedPersonReport{
+		DocType:    unidentifiedDocType,
+		ReportID:   reportID,
+		Station:    station,
+		FIRID:      input.FIRID,
+		Status:     StatusOpen,
+		Condition:  input.Condition,
+		Person:     input.Person,
+		Remarks:    input.Remarks,
+		ReportedBy: officerID,
+		ReportedAt: reportedAt,
+		TxID:       ctx.GetStub().GetTxID(),
+	}
+	if err := putReport(ctx, unidentifiedDocType, reportID, &report); err != nil {
+		return "", err
+	}
+
+	// The report was written in this transaction and cannot be read back, so it is matched as is
+	matches, truncated, err := matchUnidentifiedPerson(ctx, &report, registrationScanLimit)
+	if err != nil {
+		return "", err
+	}
+	if err := emitMatchCandidates(ctx, reportID, station, matches, truncated); err != nil {
+		return "", err
+	}
+	return reportID, nil
+}
+
+// ReadMissingPerson retrieves a missing person report. Only police may read reports, and
+// only the SHO or an IO of the reporting station sees the complainant.
+func (s *SmartContract) ReadMissingPerson(ctx contractapi.TransactionContextInterface, reportID string) (*MissingPersonReport, error) {
+	if err := common.OnlyPolice(ctx); err != nil {
+		return nil, err
+	}
+	report, err := readMissingPerson(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if err := mergeComplainant(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ReadUnidentifiedPerson retrieves an unidentified person report. Only police may read reports.
+func (s *SmartContract) ReadUnidentifiedPerson(ctx contractapi.TransactionContextInterface, reportID string) (*UnidentifiedPersonReport, error) {
+	if err := common.OnlyPolice(ctx); err != nil {
+		return nil, err
+	}
+	return readUnidentifiedPerson(ctx, reportID)
+}
+
+// newResolution returns the resolution of a report by the calling officer
+func newResolution(ctx contractapi.TransactionContextInterface, matchedWith, remarks string) (*Resolution, error) {
+	officerID, err := common.GetOfficerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resolvedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Resolution{
+		MatchedWith: matchedWith,
+		Remarks:     remarks,
+		ResolvedBy:  officerID,
+		ResolvedAt:  resolvedAt,
+		TxID:        ctx.GetStub().GetTxID(),
+	}, nil
+}
+
+// ConfirmMatch records that an unidentified person has been identified as a missing person,
+// moving both reports to Matched. Only the SHO or an IO of the station that registered the
+// missing person report may confirm a match, and the confirmation is announced in a
+// PersonMatchConfirmed event so the station that found the person is told.
+func (s *SmartContract) ConfirmMatch(ctx contractapi.TransactionContextInterface, missingReportID, unidentifiedReportID, remarks string) error {
+	missing, err := readMissingPerson(ctx, missingReportID)
+	if err != nil {
+		return err
+	}
+	if err := common.RequireStationRole(ctx, missing.Station, common.RoleSHO, common.RoleIO); err != nil {
+		return err
+	}
+	unidentified, err := readUnidentifiedPerson(ctx, unidentifiedReportID)
+	if err != nil {
+		return err
+	}
+	if missing.Status != StatusOpen {
+		return fmt.Errorf("the missing person report %s is %s", missingReportID, missing.Status)
+	}
+	if unidentified.Status != StatusOpen {
+		return fmt.Errorf("the unidentified person report %s is %s", unidentifiedReportID, unidentified.Status)
+	}
+
+	missing.Resolution, err = newResolution(ctx, unidentifiedReportID, remarks)
+	if err != nil {
+		return err
+	}
+	unidentified.Resolution, err = newResolution(ctx, missingReportID, remarks)
+	if err != nil {
+		return err
+	}
+	missing.Status = StatusMatched
+	unidentified.Status = StatusMatched
+	if err := putReport(ctx, missingDocType, missingReportID, missing); err != nil {
+		return err
+	}
+	if err := putReport(ctx, unidentifiedDocType, unidentifiedReportID, unidentified); err != nil {
+		return err
+	}
+	return emitMatchConfirmed(ctx, missing, unidentified)
+}
+
+// CloseReport closes an open report of either kind without a match, for example when a
+// missing person returns home or an unidentified person is identified by other means.
+// Only the SHO of the station that registered the report may close it, and a reason is
+// required.
+func (s *SmartContract) CloseReport(ctx contractapi.TransactionContextInterface, reportID, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("a reason is required to close report %s", reportID)
+	}
+
+	if isMissingReportID(reportID) {
+		report, err := readMissingPerson(ctx, reportID)
+		if err != nil {
+			return err
+		}
+		if err := common.RequireStationRole(ctx, report.Station, common.RoleSHO); err != nil {
+			return err
+		}
+		if report.Status != StatusOpen {
+			return fmt.Errorf("the missing person report %s is %s", reportID, report.Status)
+		}
+		report.Resolution, err = newResolution(ctx, "", reason)
+		if err != nil {
+			return err
+		}
+		report.Status = StatusClosed
+		return putReport(ctx, missingDocType, reportID, report)
+	}
+
+	report, err := readUnidentifiedPerson(ctx, reportID)
+	if err != nil {
+		return err
+	}
+	if err := common.RequireStationRole(ctx, report.Station, common.RoleSHO); err != nil {
+		return err
+	}
+	if report.Status != StatusOpen {
+		return fmt.Errorf("the unidentified person report %s is %s", reportID, report.Status)
+	}
+	report.Resolution, err = newResolution(ctx, "", reason)
+	if err != nil {
+		return err
+	}
+	report.Status = StatusClosed
+	return putReport(ctx, unidentifiedDocType, reportID, report)
+}
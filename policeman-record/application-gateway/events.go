@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// personnelEvent mirrors the payload of the personnel chaincode events
+type personnelEvent struct {
+	OfficerID        string `json:"officerId"`
+	Rank             string `json:"rank"`
+	Posting          string `json:"posting"`
+	Station          string `json:"station"`
+	EmploymentStatus string `json:"employmentStatus"`
+	Suspension       string `json:"suspension,omitempty"`
+}
+
+// listen prints a line for each personnel change as it is committed, until interrupted.
+// Pass a block number to replay changes from that block onwards. To forward personnel
+// events to another system, run the fir-record listener with CHAINCODE_NAME=policeman.
+func listen(network *client.Network, chaincodeName string, args []string) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var options []client.ChaincodeEventsOption
+	if len(args) > 0 {
+		startBlock, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			panic(fmt.Errorf("invalid start block %q: %w", args[0], err))
+		}
+		options = append(options, client.WithStartBlock(startBlock))
+	}
+
+	events, err := network.ChaincodeEvents(ctx, chaincodeName, options...)
+	if err != nil {
+		panic(fmt.Errorf("failed to start chaincode event listening: %w", err))
+	}
+
+	fmt.Printf("\n*** Listening for personnel changes on %s, press Ctrl+C to stop\n", chaincodeName)
+	for event := range events {
+		var officer personnelEvent
+		if err := json.Unmarshal(event.Payload, &officer); err != nil {
+			fmt.Fprintf(os.Stderr, "*** Ignoring %s event from tx %s: %v\n", event.EventName, event.TransactionID, err)
+			continue
+		}
+
+		fmt.Printf("\n<-- %s (block %d): %s %s, %s at %s, %s\n", event.EventName, event.BlockNumber,
+			officer.Rank, officer.OfficerID, officer.Posting, officer.Station, officer.EmploymentStatus)
+		if officer.Suspension != "" {
+			fmt.Printf("    Suspension: %s\n", officer.Suspension)
+		}
+	}
+}
@@ -13,8 +13,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
 	"os"
 	"path"
+	"strconv"
 	"time"
     "log"
 	
@@ -27,11 +29,13 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// The HR admin identity carries the role=HRAdmin certificate attribute that the personnel
+// chaincode requires. It is only issued when the network is started with Fabric CA (-ca).
 const (
 	mspID        = "Org1MSP"
 	cryptoPath   = "../../police-network/organizations/peerOrganizations/org1.example.com"
-	certPath     = cryptoPath + "/users/User1@org1.example.com/msp/signcerts"
-	keyPath      = cryptoPath + "/users/User1@org1.example.com/msp/keystore"
+	certPath     = cryptoPath + "/users/HRAdmin@org1.example.com/msp/signcerts"
+	keyPath      = cryptoPath + "/users/HRAdmin@org1.example.com/msp/keystore"
 	tlsCertPath  = cryptoPath + "/peers/peer0.org1.example.com/tls/ca.crt"
 	peerEndpoint = "dns:///localhost:7051"
 	gatewayPeer  = "peer0.org1.example.com"
@@ -79,17 +83,25 @@ func main() {
 	network := gw.GetNetwork(channelName)
 	contract := network.GetContract(chaincodeName)
 
+	// "go run . listen [startBlock]" prints personnel events instead of running the demo
+	if len(os.Args) > 1 && os.Args[1] == "listen" {
+		listen(network, chaincodeName, os.Args[2:])
+		return
+	}
+
 	initLedger(contract)
     createPolicePersonnel(contract)
     readPolicePersonnel(contract, "POL12345")
     updatePolicePersonnel(contract)
-    deletePolicePersonnel(contract, "POL12345")
-    exists, err := personnelExists(contract, "POL12345")
+    // POL12345 is the seeded SHO that files FIRs, so the demo removes the officer it created instead
+    deletePolicePersonnel(contract, "POL12346")
+    exists, err := personnelExists(contract, "POL12346")
     if err != nil {
      panic(err)
     }
     fmt.Printf("Personnel exists: %t\n", exists)
     getAllPersonnel(contract)
+    listAllPersonnel(contract)
 
 }
 
@@ -196,6 +208,60 @@ func getAllPersonnel(contract *client.Contract) {
 	fmt.Printf("*** Result:%s\n", result)
 }
 
+func listAllPersonnel(contract *client.Contract) {
+	fmt.Println("\n--> Evaluate Transaction: GetPersonnelWithPagination, walks every page of police personnel records")
+
+	count := 0
+	for record, err := range allRecords(contract, "GetPersonnelWithPagination", 10) {
+		if err != nil {
+			panic(err)
+		}
+		count++
+		fmt.Printf("*** [%d] %s\n", count, record)
+	}
+
+	fmt.Printf("*** %d records in total\n", count)
+}
+
+// recordPage mirrors the paginated query result returned by the chaincode
+type recordPage struct {
+	Records             []json.RawMessage `json:"records"`
+	FetchedRecordsCount int32             `json:"fetchedRecordsCount"`
+	Bookmark            string            `json:"bookmark"`
+}
+
+// allRecords walks every page of a paginated chaincode query, fetching the next page only
+// when the caller has consumed the current one.
+func allRecords(contract *client.Contract, transactionName string, pageSize int32) iter.Seq2[json.RawMessage, error] {
+	return func(yield func(json.RawMessage, error) bool) {
+		bookmark := ""
+		for {
+			result, err := contract.EvaluateTransaction(transactionName, strconv.Itoa(int(pageSize)), bookmark)
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to evaluate %s: %w", transactionName, err))
+				return
+			}
+
+			var page recordPage
+			if err := json.Unmarshal(result, &page); err != nil {
+				yield(nil, fmt.Errorf("failed to parse %s page: %w", transactionName, err))
+				return
+			}
+
+			for _, record := range page.Records {
+				if !yield(record, nil) {
+					return
+				}
+			}
+
+			if page.Bookmark == "" || page.Bookmark == bookmark || page.FetchedRecordsCount < pageSize {
+				return
+			}
+			bookmark = page.Bookmark
+		}
+	}
+}
+
 func createPolicePersonnel(contract *client.Contract) {
 	fmt.Printf("\n--> Submit Transaction: CreatePolicePersonnel, creates a new police personnel record\n")
 
@@ -204,11 +270,10 @@ func createPolicePersonnel(contract *client.Contract) {
 	rank := "Sub-Inspector"
 	dob := "1990-01-25"
 	posting := "Crime Branch, Delhi"
+	station := "DEL-CRB"
 	badgeNumber := "DEL-7890"
 	employmentStatus := "Active"
 	dateOfJoining := "2015-07-10"
-	lastUpdatedBy := "Org1"
-	lastUpdatedOn := time.Now().Format("2006-01-02")
 	award := "Meritorious Service Medal"
 	suspension := "" // or any suspension notes as plain string
 
@@ -219,13 +284,12 @@ func createPolicePersonnel(contract *client.Contract) {
 		rank,
 		dob,
 		posting,
+		station,
 		badgeNumber,
 		employmentStatus,
 		dateOfJoining,
 		award,
 		suspension,
-		lastUpdatedBy,
-		lastUpdatedOn,
 	)
 	if err != nil {
 		log.Fatalf("Failed to Submit transaction: %v\n", err)
@@ -255,11 +319,10 @@ func updatePolicePersonnel(contract *client.Contract) {
 	rank := "Sub-Inspector"
 	dob := "1990-01-25"
 	posting := "Cyber Crime Cell, Delhi"
+	station := "DEL-CYB"
 	badgeNumber := "DEL-7890"
 	employmentStatus := "Active"
 	dateOfJoining := "2015-07-10"
-	lastUpdatedBy := "Org1"
-	lastUpdatedOn := time.Now().Format("2006-01-02")
 	award := "Meritorious Service Medal, Best Investigator 2024"
 	suspension := "" // No suspensions
 
@@ -270,13 +333,12 @@ func updatePolicePersonnel(contract *client.Contract) {
 		rank,
 		dob,
 		posting,
+		station,
 		badgeNumber,
 		employmentStatus,
 		dateOfJoining,
 		award,
 		suspension,
-		lastUpdatedBy,
-		lastUpdatedOn,
 	)
 	if err != nil {
 		panic(fmt.Errorf("failed to submit transaction: %w", err))
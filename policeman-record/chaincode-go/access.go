@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Roles carried in the "role" attribute of Fabric CA enrollment certificates
+const (
+	RoleSHO       = "SHO"
+	RoleIO        = "IO"
+	RoleConstable = "Constable"
+	RoleHRAdmin   = "HRAdmin"
+)
+
+const (
+	attrRole      = "role"
+	attrOfficerID = "officerId"
+)
+
+// getUpdater returns the caller's officer ID from the officerId certificate attribute,
+// falling back to the certificate common name (the Fabric CA enrollment ID)
+func getUpdater(ctx contractapi.TransactionContextInterface) (string, error) {
+	officerID, found, err := ctx.GetClientIdentity().GetAttributeValue(attrOfficerID)
+	if err != nil {
+		return "", fmt.Errorf("unable to read %s attribute: %v", attrOfficerID, err)
+	}
+	if found && officerID != "" {
+		return officerID, nil
+	}
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return "", fmt.Errorf("unable to get client certificate: %v", err)
+	}
+	return cert.Subject.CommonName, nil
+}
+
+// requireRole enforces that the caller is a police identity whose role attribute is one of roles
+func requireRole(ctx contractapi.TransactionContextInterface, roles ...string) error {
+	if err := onlyPolice(ctx); err != nil {
+		return err
+	}
+	role, found, err := ctx.GetClientIdentity().GetAttributeValue(attrRole)
+	if err != nil {
+		return fmt.Errorf("unable to read %s attribute: %v", attrRole, err)
+	}
+	if !found || role == "" {
+		return fmt.Errorf("access denied: certificate has no %s attribute; requires %s=%s", attrRole, attrRole, strings.Join(roles, "|"))
+	}
+	for _, allowed := range roles {
+		if role == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("access denied: requires %s=%s, certificate has %s=%s", attrRole, strings.Join(roles, "|"), attrRole, role)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Personnel chaincode event names. A suspension is reported as PersonnelSuspended
+// rather than PersonnelUpdated so listeners need not compare statuses.
+const (
+	EventPersonnelCreated   = "PersonnelCreated"
+	EventPersonnelUpdated   = "PersonnelUpdated"
+	EventPersonnelSuspended = "PersonnelSuspended"
+)
+
+// employmentStatusSuspended is the EmploymentStatus that triggers PersonnelSuspended
+const employmentStatusSuspended = "Suspended"
+
+// PersonnelEvent is the payload of personnel chaincode events
+type PersonnelEvent struct {
+	OfficerID        string `json:"officerId"`
+	Rank             string `json:"rank"`
+	Posting          string `json:"posting"`
+	Station          string `json:"station"`
+	EmploymentStatus string `json:"employmentStatus"`
+	Suspension       string `json:"suspension,omitempty"`
+}
+
+// emitPersonnelEvent sets a chaincode event describing a personnel record
+func emitPersonnelEvent(ctx contractapi.TransactionContextInterface, name string, p *PolicePersonnel) error {
+	payload, err := json.Marshal(PersonnelEvent{
+		OfficerID:        p.OfficerID,
+		Rank:             p.Rank,
+		Posting:          p.Posting,
+		Station:          p.Station,
+		EmploymentStatus: p.EmploymentStatus,
+		Suspension:       p.Suspension,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().SetEvent(name, payload)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
 )
@@ -18,6 +19,7 @@ type PolicePersonnel struct {
 	Rank             string `json:"rank"`
 	DOB              string `json:"dob"`
 	Posting          string `json:"posting"`
+	Station          string `json:"station"` // station code of the posting, e.g. MUM-CYB
 	BadgeNumber      string `json:"badgeNumber"`
 	EmploymentStatus string `json:"employmentStatus"`
 	DateOfJoining    string `json:"dateOfJoining"`
@@ -29,7 +31,15 @@ type PolicePersonnel struct {
 
 // InitLedger adds a base set of assets to the ledger
 func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
-	if err := onlyPolice(ctx); err != nil {
+	if err := requireRole(ctx, RoleHRAdmin); err != nil {
+		return err
+	}
+	lastUpdatedBy, err := getUpdater(ctx)
+	if err != nil {
+		return err
+	}
+	lastUpdatedOn, err := getTxTimestamp(ctx)
+	if err != nil {
 		return err
 	}
 
@@ -40,13 +50,14 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 			Rank:             "Inspector",
 			DOB:              "1985-08-15",
 			Posting:          "Cyber Crime Unit, Mumbai",
+			Station:          "MUM-CYB",
 			BadgeNumber:      "MUM-4521",
 			EmploymentStatus: "Active",
 			DateOfJoining:    "2010-06-12",
 			Award:            "Gallantry Award 2018",
 			Suspension:       "",
-			LastUpdatedBy:    "Org1",
-			LastUpdatedOn:    "2025-04-06",
+			LastUpdatedBy:    lastUpdatedBy,
+			LastUpdatedOn:    lastUpdatedOn,
 		},
 	}
 
@@ -64,13 +75,22 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 	return nil
 }
 
-// CreatePolicePersonnel issues a new record to the ledger
+// CreatePolicePersonnel issues a new record to the ledger. LastUpdatedBy and LastUpdatedOn
+// are taken from the submitting identity and the transaction timestamp.
 func (s *SmartContract) CreatePolicePersonnel(
 	ctx contractapi.TransactionContextInterface,
-	officerID, name, rank, dob, posting, badgeNumber,
-	employmentStatus, dateOfJoining, award, suspension, lastUpdatedBy, lastUpdatedOn string,
+	officerID, name, rank, dob, posting, station, badgeNumber,
+	employmentStatus, dateOfJoining, award, suspension string,
 ) error {
-    if err := onlyPolice(ctx); err != nil {
+	if err := requireRole(ctx, RoleHRAdmin); err != nil {
+		return err
+	}
+	lastUpdatedBy, err := getUpdater(ctx)
+	if err != nil {
+		return err
+	}
+	lastUpdatedOn, err := getTxTimestamp(ctx)
+	if err != nil {
 		return err
 	}
 	exists, err := s.PersonnelExists(ctx, officerID)
@@ -87,6 +107,7 @@ func (s *SmartContract) CreatePolicePersonnel(
 		Rank:             rank,
 		DOB:              dob,
 		Posting:          posting,
+		Station:          station,
 		BadgeNumber:      badgeNumber,
 		EmploymentStatus: employmentStatus,
 		DateOfJoining:    dateOfJoining,
@@ -101,12 +122,15 @@ func (s *SmartContract) CreatePolicePersonnel(
 		return err
 	}
 
-	return ctx.GetStub().PutState(officerID, pJSON)
+	if err := ctx.GetStub().PutState(officerID, pJSON); err != nil {
+		return err
+	}
+	return emitPersonnelEvent(ctx, EventPersonnelCreated, &personnel)
 }
 
 // ReadPolicePersonnel returns a record by ID
 func (s *SmartContract) ReadPolicePersonnel(ctx contractapi.TransactionContextInterface, officerID string) (*PolicePersonnel, error) {
-	if err := onlyPolice(ctx); err != nil {
+	if err := requireRole(ctx, RoleSHO, RoleIO, RoleConstable, RoleHRAdmin); err != nil {
 		return nil, err
 	}
 
@@ -127,22 +151,28 @@ func (s *SmartContract) ReadPolicePersonnel(ctx contractapi.TransactionContextIn
 	return &personnel, nil
 }
 
-// UpdatePolicePersonnel updates a record
+// UpdatePolicePersonnel updates a record. LastUpdatedBy and LastUpdatedOn are taken from
+// the submitting identity and the transaction timestamp.
 func (s *SmartContract) UpdatePolicePersonnel(
 	ctx contractapi.TransactionContextInterface,
-	officerID, name, rank, dob, posting, badgeNumber,
-	employmentStatus, dateOfJoining, award, suspension, lastUpdatedBy, lastUpdatedOn string,
+	officerID, name, rank, dob, posting, station, badgeNumber,
+	employmentStatus, dateOfJoining, award, suspension string,
 ) error {
-	if err := onlyPolice(ctx); err != nil {
+	if err := requireRole(ctx, RoleHRAdmin); err != nil {
 		return err
 	}
-
-	exists, err := s.PersonnelExists(ctx, officerID)
+	lastUpdatedBy, err := getUpdater(ctx)
 	if err != nil {
 		return err
 	}
-	if !exists {
-		return fmt.Errorf("the officer %s does not exist", officerID)
+	lastUpdatedOn, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	previous, err := s.ReadPolicePersonnel(ctx, officerID)
+	if err != nil {
+		return err
 	}
 
 	personnel := PolicePersonnel{
@@ -151,6 +181,7 @@ func (s *SmartContract) UpdatePolicePersonnel(
 		Rank:             rank,
 		DOB:              dob,
 		Posting:          posting,
+		Station:          station,
 		BadgeNumber:      badgeNumber,
 		EmploymentStatus: employmentStatus,
 		DateOfJoining:    dateOfJoining,
@@ -165,12 +196,20 @@ func (s *SmartContract) UpdatePolicePersonnel(
 		return err
 	}
 
-	return ctx.GetStub().PutState(officerID, pJSON)
+	if err := ctx.GetStub().PutState(officerID, pJSON); err != nil {
+		return err
+	}
+
+	eventName := EventPersonnelUpdated
+	if employmentStatus == employmentStatusSuspended && previous.EmploymentStatus != employmentStatusSuspended {
+		eventName = EventPersonnelSuspended
+	}
+	return emitPersonnelEvent(ctx, eventName, &personnel)
 }
 
 // DeletePolicePersonnel deletes a record
 func (s *SmartContract) DeletePolicePersonnel(ctx contractapi.TransactionContextInterface, officerID string) error {
-	if err := onlyPolice(ctx); err != nil {
+	if err := requireRole(ctx, RoleHRAdmin); err != nil {
 		return err
 	}
 
@@ -220,11 +259,61 @@ func (s *SmartContract) GetAllPersonnel(ctx contractapi.TransactionContextInterf
 	return personnelList, nil
 }
 
+// PaginatedQueryResult holds one page of personnel records and the bookmark for the next page
+type PaginatedQueryResult struct {
+	Records             []*PolicePersonnel `json:"records,omitempty" metadata:",optional"`
+	FetchedRecordsCount int32              `json:"fetchedRecordsCount"`
+	Bookmark            string             `json:"bookmark"`
+}
+
+// GetPersonnelWithPagination returns up to pageSize records starting at bookmark
+func (s *SmartContract) GetPersonnelWithPagination(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*PaginatedQueryResult, error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var personnelList []*PolicePersonnel
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var personnel PolicePersonnel
+		err = json.Unmarshal(queryResponse.Value, &personnel)
+		if err != nil {
+			return nil, err
+		}
+		personnelList = append(personnelList, &personnel)
+	}
+
+	return &PaginatedQueryResult{
+		Records:             personnelList,
+		FetchedRecordsCount: metadata.FetchedRecordsCount,
+		Bookmark:            metadata.Bookmark,
+	}, nil
+}
+
 // Utility function: GetMSPID
 func getMSPID(ctx contractapi.TransactionContextInterface) (string, error) {
 	return ctx.GetClientIdentity().GetMSPID()
 }
 
+// getTxTimestamp returns the transaction timestamp as an RFC 3339 string
+func getTxTimestamp(ctx contractapi.TransactionContextInterface) (string, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return "", fmt.Errorf("unable to get transaction timestamp: %v", err)
+	}
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC().Format(time.RFC3339), nil
+}
+
 // Authorization check for police-only access
 func onlyPolice(ctx contractapi.TransactionContextInterface) error {
 	mspid, err := getMSPID(ctx)
@@ -0,0 +1,245 @@
+/*
+Copyright 2021 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/hash"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// User1 carries the role=SHO and station=MUM-CYB certificate attributes that property
+// register transactions require. They are only issued when the network is started with
+// Fabric CA (-ca).
+const (
+	mspID        = "Org1MSP"
+	cryptoPath   = "../../police-network/organizations/peerOrganizations/org1.example.com"
+	certPath     = cryptoPath + "/users/User1@org1.example.com/msp/signcerts"
+	keyPath      = cryptoPath + "/users/User1@org1.example.com/msp/keystore"
+	tlsCertPath  = cryptoPath + "/peers/peer0.org1.example.com/tls/ca.crt"
+	peerEndpoint = "dns:///localhost:7051"
+	gatewayPeer  = "peer0.org1.example.com"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	clientConnection := newGrpcConnection()
+	defer clientConnection.Close()
+
+	id := newIdentity()
+	sign := newSign()
+
+	gw, err := client.Connect(
+		id,
+		client.WithSign(sign),
+		client.WithHash(hash.SHA256),
+		client.WithClientConnection(clientConnection),
+		client.WithEvaluateTimeout(5*time.Second),
+		client.WithEndorseTimeout(15*time.Second),
+		client.WithSubmitTimeout(5*time.Second),
+		client.WithCommitStatusTimeout(1*time.Minute),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer gw.Close()
+
+	chaincodeName := "property"
+	if ccname := os.Getenv("CHAINCODE_NAME"); ccname != "" {
+		chaincodeName = ccname
+	}
+
+	channelName := "mychannel"
+	if cname := os.Getenv("CHANNEL_NAME"); cname != "" {
+		channelName = cname
+	}
+
+	network := gw.GetNetwork(channelName)
+	runCommand(network.GetContract(chaincodeName), os.Args[1:])
+}
+
+// runCommand runs a single command given on the command line
+func runCommand(contract *client.Contract, args []string) {
+	switch {
+	case len(args) == 3 && args[0] == "seize":
+		seizeProperty(contract, args[1], args[2])
+	case len(args) == 5 && args[0] == "move":
+		submit(contract, "MoveProperty", fmt.Sprintf("moves %s to %s", args[1], args[2]), args[1:]...)
+	case len(args) == 4 && args[0] == "reseal":
+		submit(contract, "ResealProperty", fmt.Sprintf("reseals %s", args[1]), args[1:]...)
+	case (len(args) == 5 || len(args) == 6) && args[0] == "release":
+		remarks := ""
+		if len(args) == 6 {
+			remarks = args[5]
+		}
+		submit(contract, "RecordDisposal", fmt.Sprintf("releases %s to %s", args[1], args[4]),
+			args[1], "CourtRelease", args[2], args[3], args[4], remarks)
+	case (len(args) == 5 || len(args) == 6) && args[0] == "dispose":
+		remarks := ""
+		if len(args) == 6 {
+			remarks = args[5]
+		}
+		submit(contract, "RecordDisposal", fmt.Sprintf("records %s as %s", args[1], args[2]),
+			args[1], args[2], args[3], args[4], "", remarks)
+	case len(args) == 2 && args[0] == "show":
+		evaluateAndPrint(contract, "ReadProperty", args[1])
+	case len(args) == 2 && args[0] == "fir":
+		evaluateAndPrint(contract, "GetPropertyForFIR", args[1])
+	case len(args) == 2 && args[0] == "seal":
+		evaluateAndPrint(contract, "ReadSeal", args[1])
+	case len(args) == 2 && args[0] == "stock":
+		stockReport(contract, args[1])
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: go run . <command>
+
+Commands:
+  seize <firID> <itemJSON>
+  move <itemID> <malkhana> <location> <reason>
+  reseal <itemID> <sealNumbersJSON> <reason>
+  release <itemID> <orderRef> <orderDate> <releasedTo> [remarks]
+  dispose <itemID> <Destroyed|Auctioned|Confiscated> <orderRef> <orderDate> [remarks]
+  show <itemID>
+  fir <firID>
+  seal <sealNo>
+  stock <malkhana>
+
+itemJSON carries the Category (Vehicle|Cash|Weapon|Narcotics|Other), Description,
+Quantity, Unit, Malkhana, Location, SealNumbers and SeizurePlace, for example
+{"Category":"Cash","Description":"Currency notes","Quantity":25000,"Unit":"INR",
+ "Malkhana":"MUM-CYB-MK1","Location":"Safe 1","SealNumbers":["MUM-CYB-S-0001"]}`)
+}
+
+func newGrpcConnection() *grpc.ClientConn {
+	certificatePEM, err := os.ReadFile(tlsCertPath)
+	if err != nil {
+		panic(fmt.Errorf("failed to read TLS certificate file: %w", err))
+	}
+
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		panic(err)
+	}
+
+	certPool := x509.NewCertPool()
+	certPool.AddCert(certificate)
+	transportCredentials := credentials.NewClientTLSFromCert(certPool, gatewayPeer)
+
+	connection, err := grpc.NewClient(peerEndpoint, grpc.WithTransportCredentials(transportCredentials))
+	if err != nil {
+		panic(fmt.Errorf("failed to create gRPC connection: %w", err))
+	}
+
+	return connection
+}
+
+func newIdentity() *identity.X509Identity {
+	certificatePEM, err := readFirstFile(certPath)
+	if err != nil {
+		panic(fmt.Errorf("failed to read certificate file: %w", err))
+	}
+
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		panic(err)
+	}
+
+	id, err := identity.NewX509Identity(mspID, certificate)
+	if err != nil {
+		panic(err)
+	}
+
+	return id
+}
+
+func newSign() identity.Sign {
+	privateKeyPEM, err := readFirstFile(keyPath)
+	if err != nil {
+		panic(fmt.Errorf("failed to read private key file: %w", err))
+	}
+
+	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
+	if err != nil {
+		panic(err)
+	}
+
+	sign, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		panic(err)
+	}
+
+	return sign
+}
+
+func readFirstFile(dirPath string) ([]byte, error) {
+	dir, err := os.Open(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	fileNames, err := dir.Readdirnames(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(path.Join(dirPath, fileNames[0]))
+}
+
+func seizeProperty(contract *client.Contract, firID, itemJSON string) {
+	fmt.Printf("\n--> Submit Transaction: SeizeProperty, records an item seized under %s\n", firID)
+	result, err := contract.SubmitTransaction("SeizeProperty", firID, itemJSON)
+	if err != nil {
+		panic(fmt.Errorf("failed to record seizure: %w", err))
+	}
+	fmt.Printf("*** Recorded property item %s\n", result)
+}
+
+func submit(contract *client.Contract, transactionName, description string, args ...string) {
+	fmt.Printf("\n--> Submit Transaction: %s, %s\n", transactionName, description)
+	_, err := contract.SubmitTransaction(transactionName, args...)
+	if err != nil {
+		panic(fmt.Errorf("failed to submit %s: %w", transactionName, err))
+	}
+	fmt.Println("*** Transaction committed successfully")
+}
+
+func evaluateAndPrint(contract *client.Contract, transactionName string, args ...string) {
+	fmt.Printf("\n--> Evaluate Transaction: %s\n", transactionName)
+	result, err := contract.EvaluateTransaction(transactionName, args...)
+	if err != nil {
+		panic(fmt.Errorf("failed to evaluate %s: %w", transactionName, err))
+	}
+	fmt.Printf("*** Result: %s\n", formatJSON(result))
+}
+
+// Format JSON data
+func formatJSON(data []byte) string {
+	var prettyJSON bytes.Buffer
+	if err := json.Indent(&prettyJSON, data, "", "  "); err != nil {
+		panic(fmt.Errorf("failed to parse JSON: %w", err))
+	}
+	return prettyJSON.String()
+}
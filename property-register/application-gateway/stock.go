@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// stockItem holds the fields of a property item printed in the stock-taking report
+type stockItem struct {
+	ItemID      string   `json:"ItemID"`
+	FIRID       string   `json:"FIRID"`
+	Category    string   `json:"Category"`
+	Description string   `json:"Description"`
+	Quantity    float64  `json:"Quantity"`
+	Unit        string   `json:"Unit"`
+	Location    string   `json:"Location"`
+	SealNumbers []string `json:"SealNumbers"`
+}
+
+// stockReport prints the items held in a malkhana grouped by category, with each item's
+// storage location and seals, for physical verification against the register. Items are
+// listed by location so the report can be checked rack by rack.
+func stockReport(contract *client.Contract, malkhana string) {
+	fmt.Printf("\n--> Evaluate Transaction: GetMalkhanaStock, returns the items held in %s\n", malkhana)
+	result, err := contract.EvaluateTransaction("GetMalkhanaStock", malkhana)
+	if err != nil {
+		panic(fmt.Errorf("failed to evaluate GetMalkhanaStock: %w", err))
+	}
+
+	var items []stockItem
+	if len(result) > 0 {
+		if err := json.Unmarshal(result, &items); err != nil {
+			panic(fmt.Errorf("failed to parse malkhana stock: %w", err))
+		}
+	}
+
+	byCategory := make(map[string][]stockItem)
+	for _, item := range items {
+		byCategory[item.Category] = append(byCategory[item.Category], item)
+	}
+	categories := make([]string, 0, len(byCategory))
+	for category := range byCategory {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	fmt.Printf("\nStock-taking report for malkhana %s, %s\n", malkhana, time.Now().Format("2006-01-02 15:04"))
+	fmt.Printf("%d items in custody\n", len(items))
+
+	for _, category := range categories {
+		group := byCategory[category]
+		sort.Slice(group, func(i, j int) bool {
+			if group[i].Location != group[j].Location {
+				return group[i].Location < group[j].Location
+			}
+			return group[i].ItemID < group[j].ItemID
+		})
+
+		// Quantities are only comparable within a unit, so totals are kept per unit
+		totals := make(map[string]float64)
+		fmt.Printf("\n%s (%d items)\n", category, len(group))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  Location\tItem\tDescription\tQuantity\tSeals\tVerified")
+		for _, item := range group {
+			totals[item.Unit] += item.Quantity
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%v %s\t%s\t[ ]\n",
+				item.Location, item.ItemID, item.Description, item.Quantity, item.Unit, strings.Join(item.SealNumbers, ", "))
+		}
+		w.Flush()
+
+		units := make([]string, 0, len(totals))
+		for unit := range totals {
+			units = append(units, unit)
+		}
+		sort.Strings(units)
+		for _, unit := range units {
+			fmt.Printf("  Total: %v %s\n", totals[unit], unit)
+		}
+	}
+}
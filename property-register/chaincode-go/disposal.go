@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/sudoice/pbc/chaincode-common"
+)
+
+// Ways a property item can leave the malkhana
+const (
+	DispositionCourtRelease = "CourtRelease"
+	DispositionDestroyed    = "Destroyed"
+	DispositionAuctioned    = "Auctioned"
+	DispositionConfiscated  = "Confiscated"
+)
+
+// DisposalOrder records the order under which a property item left the malkhana
+type DisposalOrder struct {
+	Disposition string `json:"Disposition"`
+	OrderRef    string `json:"OrderRef"`
+	OrderDate   string `json:"OrderDate"`
+	ReleasedTo  string `json:"ReleasedTo,omitempty" metadata:",optional"`
+	Remarks     string `json:"Remarks"`
+	RecordedBy  string `json:"RecordedBy"`
+	RecordedAt  string `json:"RecordedAt"`
+	TxID        string `json:"TxID"`
+}
+
+// RecordDisposal records that an item left the malkhana under a court-release or disposal
+// order. A court release must name who the item was released to; every other disposition
+// marks the item Disposed. Only the SHO of the item's station may record it, and the order
+// reference is required.
+func (s *SmartContract) RecordDisposal(ctx contractapi.TransactionContextInterface, itemID, disposition, orderRef, orderDate, releasedTo, remarks string) error {
+	item, officerID, err := readWritableProperty(ctx, itemID, common.RoleSHO)
+	if err != nil {
+		return err
+	}
+	switch disposition {
+	case DispositionCourtRelease:
+		if releasedTo == "" {
+			return fmt.Errorf("a court release of property item %s must name who it was released to", itemID)
+		}
+	case DispositionDestroyed, DispositionAuctioned, DispositionConfiscated:
+	default:
+		return fmt.Errorf("invalid disposition %q", disposition)
+	}
+	if orderRef == "" {
+		return fmt.Errorf("the disposal of property item %s requires an order reference", itemID)
+	}
+	recordedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	item.Disposal = &DisposalOrder{
+		Disposition: disposition,
+		OrderRef:    orderRef,
+		OrderDate:   orderDate,
+		ReleasedTo:  releasedTo,
+		Remarks:     remarks,
+		RecordedBy:  officerID,
+		RecordedAt:  recordedAt,
+		TxID:        ctx.GetStub().GetTxID(),
+	}
+	item.Status = StatusDisposed
+	if disposition == DispositionCourtRelease {
+		item.Status = StatusReleased
+	}
+	if err := putProperty(ctx, item); err != nil {
+		return err
+	}
+	return emitPropertyEvent(ctx, EventPropertyDisposed, item)
+}
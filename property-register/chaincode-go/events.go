@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+)
+
+// Property register event names, for an item entering the malkhana and for its
+// final disposal. Moves and reseals are left to the register's history.
+const (
+	EventPropertySeized   = "PropertySeized"
+	EventPropertyDisposed = "PropertyDisposed"
+)
+
+// PropertyEvent is the payload of property register chaincode events
+type PropertyEvent struct {
+	ItemID      string `json:"ItemID"`
+	FIRID       string `json:"FIRID"`
+	Malkhana    string `json:"Malkhana"`
+	Status      string `json:"Status"`
+	Disposition string `json:"Disposition,omitempty"`
+	OrderRef    string `json:"OrderRef,omitempty"`
+}
+
+// emitPropertyEvent sets a chaincode event describing a property item
+func emitPropertyEvent(ctx contractapi.TransactionContextInterface, name string, item *PropertyItem) error {
+	event := PropertyEvent{
+		ItemID:   item.ItemID,
+		FIRID:    item.FIRID,
+		Malkhana: item.Malkhana,
+		Status:   item.Status,
+	}
+	if item.Disposal != nil {
+		event.Disposition = item.Disposal.Disposition
+		event.OrderRef = item.Disposal.OrderRef
+	}
+	payloadJSON, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().SetEvent(name, payloadJSON)
+}
@@ -0,0 +1,26 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"log"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/sudoice/pbc/chaincode-common"
+)
+
+func main() {
+	propertyContract := new(SmartContract)
+	propertyContract.BeforeTransaction = common.RestrictCitizenPortal
+
+	propertyChaincode, err := contractapi.NewChaincode(propertyContract)
+	if err != nil {
+		log.Panicf("Error creating seized property register chaincode: %v", err)
+	}
+
+	if err := propertyChaincode.Start(); err != nil {
+		log.Panicf("Error starting seized property register chaincode: %v", err)
+	}
+}
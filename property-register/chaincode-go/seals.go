@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/sudoice/pbc/chaincode-common"
+)
+
+const sealKeyPrefix = "seal"
+
+// SealRecord reserves a seal number for the item it was first applied to. Seal records
+// are never deleted, so a seal number can be used only once in the register.
+type SealRecord struct {
+	SealNo    string `json:"SealNo"`
+	ItemID    string `json:"ItemID"`
+	AppliedBy string `json:"AppliedBy"`
+	AppliedAt string `json:"AppliedAt"`
+	TxID      string `json:"TxID"`
+}
+
+// Reseal records the replacement of an item's seals, for example after it was opened
+// for forensic examination or production in court
+type Reseal struct {
+	OldSealNumbers []string `json:"OldSealNumbers"`
+	NewSealNumbers []string `json:"NewSealNumbers"`
+	Reason         string   `json:"Reason"`
+	ResealedBy     string   `json:"ResealedBy"`
+	ResealedAt     string   `json:"ResealedAt"`
+	TxID           string   `json:"TxID"`
+}
+
+// normalizeSealNo returns the canonical form of a seal number, so that case and
+// surrounding spaces cannot be used to reuse one
+func normalizeSealNo(sealNo string) string {
+	return strings.ToUpper(strings.TrimSpace(sealNo))
+}
+
+// sealKey returns the world state key for a seal number
+func sealKey(ctx contractapi.TransactionContextInterface, sealNo string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(sealKeyPrefix, []string{sealNo})
+}
+
+// claimSeals reserves seal numbers for an item, refusing any already used in the register
+// or repeated in sealNumbers, and returns them in canonical form
+func claimSeals(ctx contractapi.TransactionContextInterface, itemID string, sealNumbers []string, appliedBy, appliedAt string) ([]string, error) {
+	if len(sealNumbers) == 0 {
+		return nil, fmt.Errorf("at least one seal number is required")
+	}
+
+	claimed := make([]string, 0, len(sealNumbers))
+	seen := make(map[string]bool)
+	for _, sealNo := range sealNumbers {
+		sealNo = normalizeSealNo(sealNo)
+		if sealNo == "" {
+			return nil, fmt.Errorf("seal numbers must not be empty")
+		}
+		if seen[sealNo] {
+			return nil, fmt.Errorf("the seal number %s is given more than once", sealNo)
+		}
+		seen[sealNo] = true
+
+		key, err := sealKey(ctx, sealNo)
+		if err != nil {
+			return nil, err
+		}
+		existingJSON, err := ctx.GetStub().GetState(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from world state: %v", err)
+		}
+		if existingJSON != nil {
+			var existing SealRecord
+			if err := json.Unmarshal(existingJSON, &existing); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("the seal number %s was already used on property item %s", sealNo, existing.ItemID)
+		}
+
+		sealJSON, err := json.Marshal(SealRecord{
+			SealNo:    sealNo,
+			ItemID:    itemID,
+			AppliedBy: appliedBy,
+			AppliedAt: appliedAt,
+			TxID:      ctx.GetStub().GetTxID(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.GetStub().PutState(key, sealJSON); err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, sealNo)
+	}
+	return claimed, nil
+}
+
+// ResealProperty replaces the seals on an item held in a malkhana with new seal numbers.
+// sealNumbersJSON is a JSON array of seal numbers, none of which may have been used before.
+// Only the SHO or an IO of the item's station may reseal, and a reason is required.
+func (s *SmartContract) ResealProperty(ctx contractapi.TransactionContextInterface, itemID, sealNumbersJSON, reason string) error {
+	item, officerID, err := readWritableProperty(ctx, itemID, common.RoleSHO, common.RoleIO)
+	if err != nil {
+		return err
+	}
+	if reason == "" {
+		return fmt.Errorf("a reason is required to reseal property item %s", itemID)
+	}
+
+	var sealNumbers []string
+	err = json.Unmarshal([]byte(sealNumbersJSON), &sealNumbers)
+	if err != nil {
+		return fmt.Errorf("seal numbers must be a JSON array of strings: %v", err)
+	}
+	resealedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	claimed, err := claimSeals(ctx, itemID, sealNumbers, officerID, resealedAt)
+	if err != nil {
+		return err
+	}
+
+	item.Reseals = append(item.Reseals, Reseal{
+		OldSealNumbers: item.SealNumbers,
+		NewSealNumbers: claimed,
+		Reason:         reason,
+		ResealedBy:     officerID,
+		ResealedAt:     resealedAt,
+		TxID:           ctx.GetStub().GetTxID(),
+	})
+	item.SealNumbers = claimed
+	return putProperty(ctx, item)
+}
+
+// ReadSeal returns the register entry of a seal number, naming the item it was applied to
+func (s *SmartContract) ReadSeal(ctx contractapi.TransactionContextInterface, sealNo string) (*SealRecord, error) {
+	sealNo = normalizeSealNo(sealNo)
+	key, err := sealKey(ctx, sealNo)
+	if err != nil {
+		return nil, err
+	}
+	sealJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if sealJSON == nil {
+		return nil, fmt.Errorf("the seal number %s has not been used", sealNo)
+	}
+
+	var seal SealRecord
+	err = json.Unmarshal(sealJSON, &seal)
+	if err != nil {
+		return nil, err
+	}
+	return &seal, nil
+}
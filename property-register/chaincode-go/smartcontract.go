@@ -0,0 +1,382 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/v2/contractapi"
+	"github.com/sudoice/pbc/chaincode-common"
+)
+
+// SmartContract provides functions for managing the seized property (malkhana) register
+type SmartContract struct {
+	contractapi.Contract
+}
+
+// Categories of seized property
+const (
+	CategoryVehicle   = "Vehicle"
+	CategoryCash      = "Cash"
+	CategoryWeapon    = "Weapon"
+	CategoryNarcotics = "Narcotics"
+	CategoryOther     = "Other"
+)
+
+// Property item states
+const (
+	StatusInMalkhana = "InMalkhana"
+	StatusReleased   = "Released"
+	StatusDisposed   = "Disposed"
+)
+
+const (
+	propertyDocType      = "property"
+	propertyKeyPrefix    = "property"
+	propertySeqKeyPrefix = "propertyseq"
+)
+
+// Movement records a change of storage location of a property item
+type Movement struct {
+	FromMalkhana string `json:"FromMalkhana"`
+	FromLocation string `json:"FromLocation"`
+	ToMalkhana   string `json:"ToMalkhana"`
+	ToLocation   string `json:"ToLocation"`
+	Reason       string `json:"Reason"`
+	MovedBy      string `json:"MovedBy"`
+	MovedAt      string `json:"MovedAt"`
+	TxID         string `json:"TxID"`
+}
+
+// PropertyItem is an item seized under a FIR and held in a malkhana
+type PropertyItem struct {
+	DocType string `json:"DocType"`
+	ItemID  string `json:"ItemID"`
+
+	// FIRID and Station follow the FIR to its new number and station once the item is
+	// next handled after a Zero FIR transfer; see readWritableProperty
+	FIRID   string `json:"FIRID"`
+	Station string `json:"Station"`
+	Status  string `json:"Status"`
+
+	Category    string  `json:"Category"`
+	Description string  `json:"Description"`
+	Quantity    float64 `json:"Quantity"`
+	Unit        string  `json:"Unit"`
+
+	// Malkhana and Location give where the item is stored, e.g. MUM-CYB-MK1 and Rack 4, Shelf B
+	Malkhana string `json:"Malkhana"`
+	Location string `json:"Location"`
+
+	// SealNumbers are the seals currently on the item; see seals.go
+	SealNumbers []string `json:"SealNumbers"`
+
+	SeizedBy     string `json:"SeizedBy"`
+	SeizedAt     string `json:"SeizedAt"`
+	SeizurePlace string `json:"SeizurePlace"`
+	TxID         string `json:"TxID"`
+
+	Movements []Movement     `json:"Movements,omitempty" metadata:",optional"`
+	Reseals   []Reseal       `json:"Reseals,omitempty" metadata:",optional"`
+	Disposal  *DisposalOrder `json:"Disposal,omitempty" metadata:",optional"`
+}
+
+// getTxTimestamp returns the transaction timestamp as an RFC 3339 string
+func getTxTimestamp(ctx contractapi.TransactionContextInterface) (string, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return "", fmt.Errorf("unable to get transaction timestamp: %v", err)
+	}
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC().Format(time.RFC3339), nil
+}
+
+// propertyKey returns the world state key for a property item
+func propertyKey(ctx contractapi.TransactionContextInterface, itemID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(propertyKeyPrefix, []string{itemID})
+}
+
+func readProperty(ctx contractapi.TransactionContextInterface, itemID string) (*PropertyItem, error) {
+	key, err := propertyKey(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	itemJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if itemJSON == nil {
+		return nil, fmt.Errorf("the property item %s does not exist", itemID)
+	}
+
+	var item PropertyItem
+	err = json.Unmarshal(itemJSON, &item)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func putProperty(ctx contractapi.TransactionContextInterface, item *PropertyItem) error {
+	key, err := propertyKey(ctx, item.ItemID)
+	if err != nil {
+		return err
+	}
+	item.DocType = propertyDocType
+
+	itemJSON, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, itemJSON)
+}
+
+// allocateItemID returns the next item number under a FIR, e.g. MUM-CYB/2025/00042/P0003.
+// Concurrent seizures under the same FIR conflict on the counter and the later one must
+// be resubmitted.
+func allocateItemID(ctx contractapi.TransactionContextInterface, firID string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(propertySeqKeyPrefix, []string{firID})
+	if err != nil {
+		return "", err
+	}
+	seqJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	var last int
+	if seqJSON != nil {
+		err = json.Unmarshal(seqJSON, &last)
+		if err != nil {
+			return "", err
+		}
+	}
+	last++
+
+	seqJSON, err = json.Marshal(last)
+	if err != nil {
+		return "", err
+	}
+	if err := ctx.GetStub().PutState(key, seqJSON); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/P%04d", firID, last), nil
+}
+
+// readWritableProperty returns a property item still held in a malkhana, checking that the
+// caller holds one of roles at the station now handling the item's FIR. An item seized
+// under a FIR since transferred to another station is redirected to the FIR's new number
+// and station, and is written that way by the caller. It returns the caller's officer ID.
+func readWritableProperty(ctx contractapi.TransactionContextInterface, itemID string, roles ...string) (*PropertyItem, string, error) {
+	item, err := readProperty(ctx, itemID)
+	if err != nil {
+		return nil, "", err
+	}
+	fir, err := common.CurrentFIR(ctx, item.FIRID)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := common.RequireStationRole(ctx, fir.Station, roles...); err != nil {
+		return nil, "", err
+	}
+	if item.Status != StatusInMalkhana {
+		return nil, "", fmt.Errorf("the property item %s has been %s", itemID, item.Status)
+	}
+	officerID, err := common.GetOfficerID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	item.FIRID = fir.FIRID
+	item.Station = fir.Station
+	return item, officerID, nil
+}
+
+// SeizeProperty records an item seized under a FIR and returns its item ID. itemJSON carries
+// the Category, Description, Quantity, Unit, Malkhana, Location, SealNumbers and
+// SeizurePlace. The FIR is checked against fir-record, and only police of the FIR's station
+// may record seizures. A FIR transferred to another station is refused; seize under the
+// number it now continues under. Every seal number must be new to the register.
+func (s *SmartContract) SeizeProperty(ctx contractapi.TransactionContextInterface, firID, itemJSON string) (string, error) {
+	fir, err := common.ReadFIR(ctx, firID)
+	if err != nil {
+		return "", err
+	}
+	if fir.TransferredTo != "" {
+		return "", fmt.Errorf("the FIR %s has been transferred and is now %s", firID, fir.TransferredTo)
+	}
+	if err := common.RequireStationRole(ctx, fir.Station, common.PoliceRoles...); err != nil {
+		return "", err
+	}
+	seizedBy, err := common.GetOfficerID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var input PropertyItem
+	err = json.Unmarshal([]byte(itemJSON), &input)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse property item: %v", err)
+	}
+	switch input.Category {
+	case CategoryVehicle, CategoryCash, CategoryWeapon, CategoryNarcotics, CategoryOther:
+	default:
+		return "", fmt.Errorf("invalid property category %q", input.Category)
+	}
+	if input.Description == "" || input.Malkhana == "" {
+		return "", fmt.Errorf("a seized item requires a description and the malkhana it is stored in")
+	}
+	if input.Quantity <= 0 {
+		return "", fmt.Errorf("invalid quantity %v", input.Quantity)
+	}
+
+	seizedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return "", err
+	}
+	itemID, err := allocateItemID(ctx, firID)
+	if err != nil {
+		return "", err
+	}
+	sealNumbers, err := claimSeals(ctx, itemID, input.SealNumbers, seizedBy, seizedAt)
+	if err != nil {
+		return "", err
+	}
+
+	item := PropertyItem{
+		ItemID:       itemID,
+		FIRID:        firID,
+		Station:      fir.Station,
+		Status:       StatusInMalkhana,
+		Category:     input.Category,
+		Description:  input.Description,
+		Quantity:     input.Quantity,
+		Unit:         input.Unit,
+		Malkhana:     input.Malkhana,
+		Location:     input.Location,
+		SealNumbers:  sealNumbers,
+		SeizedBy:     seizedBy,
+		SeizedAt:     seizedAt,
+		SeizurePlace: input.SeizurePlace,
+		TxID:         ctx.GetStub().GetTxID(),
+	}
+	if err := putProperty(ctx, &item); err != nil {
+		return "", err
+	}
+	if err := emitPropertyEvent(ctx, EventPropertySeized, &item); err != nil {
+		return "", err
+	}
+	return itemID, nil
+}
+
+// MoveProperty records a change of storage location of an item held in a malkhana. Only
+// the SHO of the item's station may move property, and a reason is required.
+func (s *SmartContract) MoveProperty(ctx contractapi.TransactionContextInterface, itemID, malkhana, location, reason string) error {
+	item, officerID, err := readWritableProperty(ctx, itemID, common.RoleSHO)
+	if err != nil {
+		return err
+	}
+	if malkhana == "" || reason == "" {
+		return fmt.Errorf("moving property item %s requires the destination malkhana and a reason", itemID)
+	}
+	movedAt, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	item.Movements = append(item.Movements, Movement{
+		FromMalkhana: item.Malkhana,
+		FromLocation: item.Location,
+		ToMalkhana:   malkhana,
+		ToLocation:   location,
+		Reason:       reason,
+		MovedBy:      officerID,
+		MovedAt:      movedAt,
+		TxID:         ctx.GetStub().GetTxID(),
+	})
+	item.Malkhana = malkhana
+	item.Location = location
+	return putProperty(ctx, item)
+}
+
+// ReadProperty retrieves a property item by ID. Only the judiciary and police of the
+// station handling the item's FIR may read it, and not once the FIR is sealed.
+func (s *SmartContract) ReadProperty(ctx contractapi.TransactionContextInterface, itemID string) (*PropertyItem, error) {
+	item, err := readProperty(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	fir, err := common.CurrentFIR(ctx, item.FIRID)
+	if err != nil {
+		return nil, err
+	}
+	if err := common.RequireStationOrJudiciary(ctx, fir.Station, common.PoliceRoles...); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetPropertyForFIR returns every item seized under a FIR, including under the numbers it
+// had before and has had since a Zero FIR transfer. Only the judiciary and police of the
+// station now handling the FIR may list them. Requires CouchDB.
+func (s *SmartContract) GetPropertyForFIR(ctx contractapi.TransactionContextInterface, firID string) ([]*PropertyItem, error) {
+	fir, err := common.CurrentFIR(ctx, firID)
+	if err != nil {
+		return nil, err
+	}
+	if err := common.RequireStationOrJudiciary(ctx, fir.Station, common.PoliceRoles...); err != nil {
+		return nil, err
+	}
+	firIDs, err := common.FIRNumbers(ctx, fir)
+	if err != nil {
+		return nil, err
+	}
+	return queryProperty(ctx, map[string]interface{}{"FIRID": map[string]interface{}{"$in": firIDs}})
+}
+
+// GetMalkhanaStock returns every item currently held in a malkhana, for stock-taking.
+// Police see the items their station is responsible for; the judiciary sees them all.
+// Requires CouchDB.
+func (s *SmartContract) GetMalkhanaStock(ctx contractapi.TransactionContextInterface, malkhana string) ([]*PropertyItem, error) {
+	selector := map[string]interface{}{"Malkhana": malkhana, "Status": StatusInMalkhana}
+	if common.OnlyJudiciary(ctx) != nil {
+		if err := common.RequireRole(ctx, common.PoliceRoles...); err != nil {
+			return nil, err
+		}
+		station, err := common.GetStation(ctx)
+		if err != nil {
+			return nil, err
+		}
+		selector["Station"] = station
+	}
+	return queryProperty(ctx, selector)
+}
+
+// queryProperty runs a CouchDB selector restricted to property items
+func queryProperty(ctx contractapi.TransactionContextInterface, selector map[string]interface{}) ([]*PropertyItem, error) {
+	selector["DocType"] = propertyDocType
+	queryJSON, err := json.Marshal(map[string]interface{}{"selector": selector})
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run query: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var items []*PropertyItem
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var item PropertyItem
+		err = json.Unmarshal(queryResponse.Value, &item)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+	return items, nil
+}